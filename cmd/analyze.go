@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	"doc0x1/text2babe/internal/analyze"
+	"doc0x1/text2babe/internal/crypto"
+	"doc0x1/text2babe/internal/style"
+)
+
+const crackCandidates = 10
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "Analyze and crack classical ciphers",
+	Long:  "Frequency analysis and automatic cracking of Caesar/ROT, Vigenère, Atbash and XOR ciphertexts.",
+}
+
+var analyzeCrackCmd = &cobra.Command{
+	Use:   "crack [data]",
+	Short: "Rank candidate plaintexts for a classical ciphertext",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showCrackResults(strings.Join(args, " "))
+	},
+}
+
+var analyzeFreqCmd = &cobra.Command{
+	Use:   "freq [data]",
+	Short: "Show letter frequencies compared to English",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showFrequencies(strings.Join(args, " "))
+	},
+}
+
+func init() {
+	analyzeCmd.AddCommand(analyzeCrackCmd)
+	analyzeCmd.AddCommand(analyzeFreqCmd)
+}
+
+func handleAnalyzeCommand(parts []string) {
+	if len(parts) < 3 {
+		fmt.Println("Usage: analyze crack <data> | analyze freq <data>")
+		return
+	}
+	data := strings.Join(parts[2:], " ")
+	var err error
+	switch strings.ToLower(parts[1]) {
+	case "crack":
+		err = showCrackResults(data)
+	case "freq", "frequency":
+		err = showFrequencies(data)
+	default:
+		err = fmt.Errorf("unknown analyze command: %s", parts[1])
+	}
+	if err != nil {
+		fmt.Println(style.ErrorMsg(err))
+	}
+}
+
+// crackJSON is the --json form of a cracked candidate
+type crackJSON struct {
+	Method    string  `json:"method"`
+	Key       string  `json:"key,omitempty"`
+	Score     float64 `json:"score"`
+	Plaintext string  `json:"plaintext"`
+}
+
+// letterJSON is the --json form of one letter's frequency
+type letterJSON struct {
+	Letter   string  `json:"letter"`
+	Percent  float64 `json:"percent"`
+	Expected float64 `json:"expected"`
+}
+
+func showCrackResults(data string) error {
+	// XOR attacks work on the decoded bytes, the same way decrypt reads input.
+	// Text that isn't hex/base64/binary is not XOR ciphertext.
+	var raw []byte
+	formats := crypto.DetectFormat(data, false)
+	if len(formats) > 0 {
+		raw = formats[0].Bytes
+		if note := ambiguityNote(formats); note != "" {
+			fmt.Fprintln(statusOut, style.Gray.Sprintf("%s; XOR attacks use %s", note, formats[0].Format))
+		}
+	}
+	candidates := analyze.Crack(data, raw, crackCandidates)
+	if len(candidates) == 0 {
+		return fmt.Errorf("nothing to analyze")
+	}
+
+	if jsonOutput {
+		out := make([]crackJSON, len(candidates))
+		for i, c := range candidates {
+			out[i] = crackJSON{Method: c.Method, Key: c.Key, Score: c.Score, Plaintext: printable(c.Plaintext)}
+		}
+		return printJSON(map[string][]crackJSON{"candidates": out})
+	}
+
+	fmt.Println(style.Section("🔎 Candidate Plaintexts:"))
+	for i, c := range candidates {
+		method := c.Method
+		if c.Key != "" {
+			method += " " + c.Key
+		}
+		fmt.Printf("  %s %s %s\n",
+			style.Accent.Sprintf("%2d.", i+1),
+			style.Cyan.Sprintf("%-22s", method),
+			style.Gray.Sprintf("(score %.2f)", c.Score))
+		fmt.Printf("      %s\n", style.White.Sprint(printable(c.Plaintext)))
+	}
+	fmt.Println()
+	return nil
+}
+
+func showFrequencies(data string) error {
+	rows := analyze.Frequencies([]byte(data))
+	if len(rows) == 0 {
+		return fmt.Errorf("no letters to analyze")
+	}
+
+	if jsonOutput {
+		out := make([]letterJSON, len(rows))
+		for i, r := range rows {
+			out[i] = letterJSON{Letter: string(r.Letter), Percent: r.Percent, Expected: r.Expected}
+		}
+		return printJSON(struct {
+			Letters []letterJSON `json:"letters"`
+			IC      float64      `json:"index_of_coincidence"`
+		}{out, analyze.IndexOfCoincidence([]byte(data))})
+	}
+
+	fmt.Println(style.Section("📊 Letter Frequencies:"))
+	for _, r := range rows {
+		fmt.Printf("  %s %s %s\n",
+			style.Cyan.Sprintf("%c", r.Letter),
+			style.White.Sprintf("%-30s", analyze.FrequencyBar(r.Percent)),
+			style.Gray.Sprintf("(English %.1f%%)", r.Expected))
+	}
+	fmt.Printf("  %s %.4f %s\n", style.Info.Sprint("Index of coincidence:"), analyze.IndexOfCoincidence([]byte(data)), style.Gray.Sprint("(English ≈ 0.067, random ≈ 0.038)"))
+	fmt.Println()
+	return nil
+}
+
+// printable escapes control and invalid bytes so candidates don't garble the terminal
+func printable(data []byte) string {
+	if utf8.Valid(data) {
+		clean := true
+		for _, b := range data {
+			if b < 0x20 && b != '\n' && b != '\t' {
+				clean = false
+				break
+			}
+		}
+		if clean {
+			return string(data)
+		}
+	}
+	return strings.Trim(fmt.Sprintf("%q", data), `"`)
+}
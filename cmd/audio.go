@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"doc0x1/text2babe/internal/audio"
+	"doc0x1/text2babe/internal/style"
+)
+
+// writeAudio modulates the encrypted result into a WAV file and optionally
+// sends it to Discord, where it can be played back as a voice message
+func writeAudio(result, path string, sendDiscord bool) error {
+	wav := audio.Encode([]byte(result))
+	if err := os.WriteFile(path, wav, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	seconds := float64(len(wav)-44) / 2 / audio.SampleRate
+	fmt.Fprintln(statusOut, style.Success.Sprintf("🔊 Audio saved to %s (%s)", path, time.Duration(seconds*float64(time.Second)).Round(100*time.Millisecond)))
+
+	if sendDiscord {
+		discord := cfg.GetDiscord()
+		if err := discord.SendFile(filepath.Base(path), bytes.NewReader(wav)); err != nil {
+			return fmt.Errorf("failed to send audio to Discord: %w", err)
+		}
+		fmt.Fprintln(statusOut, style.Success.Sprint("📨 Audio sent to Discord!"))
+	}
+	return nil
+}
+
+// readAudio demodulates the text carried by a WAV file
+func readAudio(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	payload, err := audio.Decode(f)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return string(payload), nil
+}
@@ -1,14 +1,167 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/spf13/cobra"
+
+	"doc0x1/text2babe/internal/config"
+	"doc0x1/text2babe/internal/style"
+	"doc0x1/text2babe/pkg/prompt"
 )
 
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Show current configuration",
-	Long:  "Display the current encryption/decryption settings.",
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Display the current encryption/decryption settings and where each came from.
+
+Settings are read from $XDG_CONFIG_HOME/text2babe/config.toml and from
+TEXT2BABE_* environment variables such as TEXT2BABE_OUTPUT. Flags override
+environment variables, which override the file, which overrides the defaults.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if jsonOutput {
+			return printJSON(currentSettings())
+		}
 		showSettings()
+		return nil
+	},
+}
+
+var configSaveCmd = &cobra.Command{
+	Use:   "save",
+	Short: "Save the current settings to the config file",
+	Long: `Write the settings that differ from the defaults to the config file.
+Flags given with this command are saved too. A key is saved only as a
+reference such as file:<path>; passwords are never written.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := saveSettings()
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(map[string]string{"saved": path})
+		}
+		return nil
 	},
 }
+
+var configResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Remove saved settings from the config file",
+	Long:  "Delete the saved settings so the defaults apply again. Recipes are kept.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := resetSettings()
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(map[string]string{"reset": path})
+		}
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configSaveCmd)
+	configCmd.AddCommand(configResetCmd)
+}
+
+func handleConfigCommand(sub string, p *prompt.Prompt) {
+	var err error
+	switch strings.ToLower(sub) {
+	case "show":
+		showSettings()
+	case "save":
+		_, err = saveSettings()
+	case "reset":
+		_, err = resetSettings()
+		p.UpdatePrompt(cfg.Mode)
+	default:
+		err = fmt.Errorf("unknown config command: %s (use save or reset)", sub)
+	}
+	if err != nil {
+		fmt.Println(style.ErrorMsg(err))
+	}
+}
+
+// saveSettings writes the current settings and returns the config file path
+func saveSettings() (string, error) {
+	path, err := config.FilePath()
+	if err != nil {
+		return "", err
+	}
+	if err := cfg.SaveSettings(); err != nil {
+		return "", fmt.Errorf("failed to save settings: %w", err)
+	}
+	fmt.Fprintln(statusOut, style.Success.Sprintf("✓ Settings saved to %s", path))
+	if !cfg.IsDefaultKey() && cfg.KeyRef == "" {
+		fmt.Fprintln(statusOut, style.WarningMsg("the key was entered as a password and is not saved; use --key-file or 'set key file:<path>' to keep it"))
+	}
+	return path, nil
+}
+
+// resetSettings clears the saved settings and reloads the defaults
+func resetSettings() (string, error) {
+	path, err := config.FilePath()
+	if err != nil {
+		return "", err
+	}
+	if err := config.ResetSettings(); err != nil {
+		return "", fmt.Errorf("failed to reset settings: %w", err)
+	}
+	*cfg = *config.New()
+	fmt.Fprintln(statusOut, style.Success.Sprint("✓ Settings reset to defaults"))
+	return path, nil
+}
+
+// settingsJSON is the --json form of the current configuration
+type settingsJSON struct {
+	Mode        string `json:"mode"`
+	DataType    string `json:"data_type"`
+	Output      string `json:"output"`
+	InputFormat string `json:"input_format"`
+	Encryption  bool   `json:"encryption"`
+	Cipher      string `json:"cipher"`
+	KeyID       string `json:"key_id"`
+	DefaultKey  bool   `json:"default_key"`
+	Clipboard   bool   `json:"clipboard"`
+	QR          bool   `json:"qr"`
+	FEC         int    `json:"fec"`
+	Classic     string `json:"classic,omitempty"`
+	Recipe      string `json:"recipe,omitempty"`
+	Discord     bool   `json:"discord"`
+	DiscordID   string `json:"discord_id,omitempty"`
+
+	Sources      map[string]config.Source `json:"sources"` // where each setting came from
+	EnvOverrides []string                 `json:"env_overrides,omitempty"`
+}
+
+func currentSettings() settingsJSON {
+	discord := cfg.GetDiscord()
+	sources := map[string]config.Source{}
+	for _, name := range config.Persisted {
+		sources[name] = cfg.SourceOf(name)
+	}
+	return settingsJSON{
+		Mode:         cfg.Mode,
+		DataType:     cfg.DataType,
+		Output:       cfg.OutputMode,
+		InputFormat:  cfg.InputFormat,
+		Encryption:   cfg.UseEncryption,
+		Cipher:       cfg.Cipher,
+		KeyID:        cfg.KeyID(),
+		DefaultKey:   cfg.IsDefaultKey(),
+		Clipboard:    cfg.Clipboard,
+		QR:           cfg.ShowQR,
+		FEC:          cfg.FEC,
+		Classic:      cfg.Classic,
+		Recipe:       cfg.Recipe,
+		Discord:      cfg.SendToDiscord && discord.IsEnabled(),
+		DiscordID:    discord.GetDMID(),
+		Sources:      sources,
+		EnvOverrides: cfg.EnvOverrides,
+	}
+}
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"encoding/base64"
 	"fmt"
+	"os"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/atotto/clipboard"
 	"github.com/spf13/cobra"
@@ -11,23 +14,153 @@ import (
 	"doc0x1/text2babe/internal/style"
 )
 
+var (
+	inputFormat string
+	saveFile    string
+	audioIn     string
+)
+
+// lastDecrypted holds the most recent plaintext for the shell's save command
+var lastDecrypted []byte
+
+// hexdumpPreview caps how much binary plaintext is dumped to the terminal
+const hexdumpPreview = 512
+
 var decryptCmd = &cobra.Command{
-	Use:   "decrypt [data]",
+	Use:   "decrypt [data|-]",
 	Short: "Decrypt data using current settings",
-	Long:  "Decrypt AES-GCM encrypted data using the current configuration.",
-	Args:  cobra.MinimumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		data := strings.Join(args, " ")
-		result, err := crypto.DecryptData(data, cfg)
+	Long: `Decrypt AES-GCM or ChaCha20-Poly1305 encrypted data using the current configuration and flags.
+With no data, or "-", the input is read from stdin. When stdout isn't a terminal the raw plaintext is written to it.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		setupPipeMode()
+		var data string
+		var err error
+		if audioIn != "" {
+			data, err = readAudio(audioIn)
+		} else {
+			data, _, err = readArgsOrStdin(args)
+		}
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
-			return
+			return err
 		}
-		fmt.Println(style.Result("Decrypted", result))
-		if err := clipboard.WriteAll(result); err != nil {
-			fmt.Println(style.WarningMsg("Failed to copy to clipboard: " + err.Error()))
-		} else {
-			fmt.Println(style.SuccessWithClipboard("Decrypted"))
+		if recipeName != "" {
+			if _, ok := cfg.Recipes[recipeName]; !ok {
+				return usageError(fmt.Errorf("no recipe named %s", recipeName))
+			}
+			cfg.Recipe = recipeName
+		}
+		if inputFormat != "" && !cfg.SetInputFormat(inputFormat) {
+			return usageError(fmt.Errorf("invalid input format %q (use %s)", inputFormat, strings.Join(crypto.InputFormats(), ", ")))
+		}
+		noteAmbiguousInput(data)
+		result, err := decryptInput(data)
+		if err != nil {
+			return err
+		}
+		reportCorrections(result)
+		if saveFile != "" {
+			if err := saveOutput(saveFile, result.Plaintext); err != nil {
+				return err
+			}
 		}
+		switch {
+		case jsonOutput:
+			return printJSON(newDecryptJSON(result, saveFile))
+		case saveFile != "":
+		case pipeMode:
+			_, err = os.Stdout.Write(result.Plaintext)
+			return err
+		default:
+			showDecrypted(result)
+		}
+		return nil
 	},
-}
\ No newline at end of file
+}
+
+// decryptJSON is the --json form of a decrypt result. Plaintext that isn't
+// valid UTF-8 is base64 encoded.
+type decryptJSON struct {
+	Result    string `json:"result,omitempty"`
+	Encoding  string `json:"encoding"`
+	Format    string `json:"format"`
+	KeyID     string `json:"key_id,omitempty"`
+	Corrected int    `json:"corrected,omitempty"`
+	File      string `json:"file,omitempty"`
+}
+
+func newDecryptJSON(result *crypto.Decrypted, file string) decryptJSON {
+	out := decryptJSON{Encoding: "utf-8", Format: result.Format, Corrected: result.Corrected, File: file}
+	if cfg.UseEncryption && cfg.Recipe == "" {
+		out.KeyID = cfg.KeyID()
+	}
+	if file != "" {
+		return out
+	}
+	if utf8.Valid(result.Plaintext) {
+		out.Result = string(result.Plaintext)
+	} else {
+		out.Encoding = "base64"
+		out.Result = base64.StdEncoding.EncodeToString(result.Plaintext)
+	}
+	return out
+}
+
+// showDecrypted prints plaintext and copies it to the clipboard. Plaintext
+// that isn't valid UTF-8 is shown as a hexdump and kept off the clipboard.
+func showDecrypted(result *crypto.Decrypted) {
+	plaintext := result.Plaintext
+	lastDecrypted = plaintext
+	if !utf8.Valid(plaintext) {
+		fmt.Println(style.Result("Decrypted", fmt.Sprintf("%d bytes of binary data", len(plaintext))))
+		preview := plaintext
+		if len(preview) > hexdumpPreview {
+			preview = preview[:hexdumpPreview]
+		}
+		fmt.Println(crypto.Hexdump(preview))
+		if len(plaintext) > len(preview) {
+			fmt.Println(style.Gray.Sprintf("... %d more bytes", len(plaintext)-len(preview)))
+		}
+		fmt.Println(style.Info.Sprint("Binary data was not copied to the clipboard; use 'save <file>' or --save to write it out"))
+		return
+	}
+
+	text := string(plaintext)
+	fmt.Println(style.Result("Decrypted", text))
+	copyResult("Decrypted", text)
+}
+
+// copyResult copies text to the clipboard unless clipboard copying is off
+func copyResult(label, text string) {
+	if !cfg.Clipboard {
+		return
+	}
+	if err := clipboard.WriteAll(text); err != nil {
+		fmt.Println(style.WarningMsg("Failed to copy to clipboard: " + err.Error()))
+	} else {
+		fmt.Println(style.SuccessWithClipboard(label))
+	}
+}
+
+// reportCorrections notes any bytes repaired by forward error correction
+func reportCorrections(result *crypto.Decrypted) {
+	if result.Corrected > 0 {
+		fmt.Fprintln(statusOut, style.WarningMsg(fmt.Sprintf("Repaired %d damaged byte(s) with Reed-Solomon error correction", result.Corrected)))
+	}
+}
+
+// saveOutput writes decrypted bytes to a file readable only by the user
+func saveOutput(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save %s: %w", path, err)
+	}
+	fmt.Fprintln(statusOut, style.Success.Sprintf("💾 Saved %d bytes to %s", len(data), path))
+	return nil
+}
+
+func init() {
+	decryptCmd.Flags().StringVar(&recipeName, "recipe", "", "Apply a stored recipe in reverse")
+	decryptCmd.Flags().StringVar(&audioIn, "audio", "", "Demodulate the data from an AFSK modem WAV file")
+	decryptCmd.Flags().StringVarP(&saveFile, "out", "o", "", "Write the decrypted bytes to a file instead of printing them")
+	decryptCmd.Flags().StringVar(&saveFile, "save", "", "Same as --out")
+	decryptCmd.Flags().StringVar(&inputFormat, "input-format", "", "Input format: auto, armor, hexdump, hex, base64, binary, url, html, unicode, qp or text (skips detection)")
+}
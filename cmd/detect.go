@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"doc0x1/text2babe/internal/crypto"
+	"doc0x1/text2babe/internal/style"
+)
+
+var detectCmd = &cobra.Command{
+	Use:   "detect [data]",
+	Short: "Show which input formats the data could be",
+	Long:  "Score every known input format and list the candidates with their confidence.",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showFormatCandidates(strings.Join(args, " "))
+	},
+}
+
+// formatJSON is the --json form of a detected input format
+type formatJSON struct {
+	Format     string  `json:"format"`
+	Confidence float64 `json:"confidence"`
+	Bytes      int     `json:"bytes"`
+}
+
+func showFormatCandidates(data string) error {
+	candidates := crypto.DetectFormat(data, cfg.UseEncryption)
+	if len(candidates) == 0 {
+		return crypto.ErrUnknownFormat
+	}
+
+	if jsonOutput {
+		out := make([]formatJSON, len(candidates))
+		for i, c := range candidates {
+			out[i] = formatJSON{Format: c.Format, Confidence: c.Confidence, Bytes: len(c.Bytes)}
+		}
+		return printJSON(struct {
+			Candidates []formatJSON `json:"candidates"`
+			Ambiguous  bool         `json:"ambiguous"`
+		}{out, crypto.Ambiguous(candidates)})
+	}
+
+	fmt.Println(style.Section("🔍 Input Format Candidates:"))
+	for i, c := range candidates {
+		fmt.Printf("  %s %s %s %s\n",
+			style.Accent.Sprintf("%d.", i+1),
+			style.Cyan.Sprintf("%-8s", c.Format),
+			style.White.Sprintf("%3.0f%%", c.Confidence*100),
+			style.Gray.Sprintf("(%d bytes)", len(c.Bytes)))
+	}
+	if note := ambiguityNote(candidates); note != "" {
+		fmt.Println(style.Gray.Sprintf("  %s; use 'set input <format>' to choose", note))
+	}
+	fmt.Println()
+	return nil
+}
+
+// noteAmbiguousInput warns when auto-detection had to pick between close candidates
+func noteAmbiguousInput(data string) {
+	if cfg.InputFormat != "auto" || cfg.Recipe != "" {
+		return
+	}
+	if note := ambiguityNote(crypto.DetectFormat(data, cfg.UseEncryption)); note != "" {
+		fmt.Fprintln(statusOut, style.Gray.Sprintf("%s; use 'set input <format>' to choose", note))
+	}
+}
+
+// ambiguityNote describes the close candidates, or is empty when one format clearly won
+func ambiguityNote(candidates []crypto.FormatCandidate) string {
+	if !crypto.Ambiguous(candidates) {
+		return ""
+	}
+	var options []string
+	for _, c := range candidates {
+		options = append(options, fmt.Sprintf("%s %.0f%%", c.Format, c.Confidence*100))
+	}
+	return fmt.Sprintf("Input format is ambiguous (%s)", strings.Join(options, ", "))
+}
@@ -2,44 +2,153 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
 
-	"github.com/atotto/clipboard"
 	"github.com/spf13/cobra"
 
-	"doc0x1/text2babe/internal/crypto"
 	"doc0x1/text2babe/internal/style"
 )
 
+var (
+	qrFlag     bool
+	qrOut      string
+	stegoCover string
+	audioOut   string
+	encryptOut string
+)
+
 var encryptCmd = &cobra.Command{
-	Use:   "encrypt [data]",
+	Use:   "encrypt [data|-]",
 	Short: "Encrypt data using current settings",
-	Long:  "Encrypt text or binary data using AES-GCM encryption with the current configuration.",
-	Args:  cobra.MinimumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		data := strings.Join(args, " ")
-		result, err := crypto.EncryptData(data, cfg)
+	Long: `Encrypt text or binary data using AES-GCM or ChaCha20-Poly1305 with the current configuration and flags.
+With no data, or "-", the input is read from stdin. When stdout isn't a terminal only the result is printed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		setupPipeMode()
+		data, fromStdin, err := readArgsOrStdin(args)
+		if err != nil {
+			return err
+		}
+		if fromStdin && cfg.DataType == "binary" {
+			// Piped bytes are already raw; don't treat them as a path or hex/base64
+			cfg.DataType = "text"
+		}
+		if recipeName != "" {
+			if _, ok := cfg.Recipes[recipeName]; !ok {
+				return usageError(fmt.Errorf("no recipe named %s", recipeName))
+			}
+			cfg.Recipe = recipeName
+		}
+		if stegoCover != "" {
+			cfg.StegoCover = stegoCover
+		}
+		if err := checkKey(false); err != nil {
+			return err
+		}
+		result, err := encryptInput(data)
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
-			return
-		}
-		fmt.Println(style.Result("Encrypted", result))
-		
-		// Copy to clipboard
-		if err := clipboard.WriteAll(result); err != nil {
-			fmt.Println(style.WarningMsg("Failed to copy to clipboard: " + err.Error()))
-		} else {
-			fmt.Println(style.SuccessWithClipboard("Encrypted"))
-		}
-		
-		// Send to Discord if enabled
+			return err
+		}
+		switch {
+		case encryptOut != "":
+			if err := saveOutput(encryptOut, []byte(result+"\n")); err != nil {
+				return err
+			}
+		case jsonOutput:
+		case pipeMode:
+			fmt.Println(result)
+		default:
+			fmt.Println(style.Result("Encrypted", result))
+		}
+
+		if qrFlag || cfg.ShowQR {
+			if err := showQR(result); err != nil {
+				fmt.Fprintln(statusOut, style.WarningMsg("Failed to render QR code: "+err.Error()))
+			}
+		}
+		if qrOut != "" {
+			files, err := writeQRFiles(result, qrOut)
+			if err != nil {
+				fmt.Fprintln(statusOut, style.WarningMsg("Failed to write QR image: "+err.Error()))
+			}
+			for _, f := range files {
+				fmt.Fprintln(statusOut, style.Success.Sprintf("🖼  QR code saved to %s", f))
+			}
+		}
+
+		if encryptOut == "" {
+			copyResult("Encrypted", result)
+		}
+
+		// Send to Discord if enabled; with --audio the WAV goes instead of the text
 		discord := cfg.GetDiscord()
-		if cfg.SendToDiscord && discord.IsEnabled() {
+		sendDiscord := cfg.SendToDiscord && discord.IsEnabled()
+		var sendErr error
+		if audioOut != "" {
+			if err := writeAudio(result, audioOut, sendDiscord); err != nil {
+				fmt.Fprintln(statusOut, style.WarningMsg(err.Error()))
+				sendErr = err
+			}
+		} else if sendDiscord {
 			if err := discord.SendEncryptedData(result, cfg.Mode); err != nil {
-				fmt.Println(style.WarningMsg("Failed to send to Discord: " + err.Error()))
+				fmt.Fprintln(statusOut, style.WarningMsg("Failed to send to Discord: "+err.Error()))
+				sendErr = fmt.Errorf("failed to send to Discord: %w", err)
 			} else {
-				fmt.Println(style.Success.Sprint("📨 Sent to Discord!"))
+				fmt.Fprintln(statusOut, style.Success.Sprint("📨 Sent to Discord!"))
+			}
+		}
+
+		if jsonOutput {
+			out := encryptJSON{Result: result, Format: cfg.OutputMode, Encrypted: cfg.UseEncryption, File: encryptOut}
+			if cfg.UseEncryption {
+				out.Cipher = cfg.Cipher
+				out.KeyID = cfg.KeyID()
+			}
+			if cfg.Recipe != "" {
+				out.Format = "recipe:" + cfg.Recipe
+			}
+			if sendDiscord {
+				out.Discord = &discordJSON{Sent: sendErr == nil}
+				if sendErr != nil {
+					out.Discord.Error = sendErr.Error()
+					out.Discord.Code = discordErrorCode(sendErr)
+				}
+			}
+			if err := printJSON(out); err != nil {
+				return err
 			}
 		}
+		if sendErr != nil {
+			// The warning and the JSON result already describe the failure
+			ce := discordError(sendErr)
+			ce.reported = true
+			return ce
+		}
+		return nil
 	},
-}
\ No newline at end of file
+}
+
+// encryptJSON is the --json form of an encrypt result
+type encryptJSON struct {
+	Result    string       `json:"result"`
+	Format    string       `json:"format"`
+	Encrypted bool         `json:"encrypted"`
+	Cipher    string       `json:"cipher,omitempty"`
+	KeyID     string       `json:"key_id,omitempty"`
+	File      string       `json:"file,omitempty"`
+	Discord   *discordJSON `json:"discord,omitempty"`
+}
+
+// discordJSON reports whether a result was sent to Discord
+type discordJSON struct {
+	Sent  bool   `json:"sent"`
+	Error string `json:"error,omitempty"`
+	Code  int    `json:"code,omitempty"` // Discord API error code
+}
+
+func init() {
+	encryptCmd.Flags().StringVarP(&encryptOut, "out", "o", "", "Write the result to a file instead of stdout")
+	encryptCmd.Flags().StringVar(&recipeName, "recipe", "", "Apply a stored recipe")
+	encryptCmd.Flags().BoolVar(&qrFlag, "qr", false, "Show the result as a QR code in the terminal")
+	encryptCmd.Flags().StringVar(&qrOut, "qr-out", "", "Write the result as a QR code PNG file")
+	encryptCmd.Flags().StringVar(&audioOut, "audio", "", "Also write the result as an AFSK modem WAV file")
+	encryptCmd.Flags().StringVar(&stegoCover, "stego", "", "Hide the result as zero-width characters in this cover text")
+}
@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"doc0x1/text2babe/internal/config"
+	"doc0x1/text2babe/internal/paper"
+	"doc0x1/text2babe/internal/style"
+	"doc0x1/text2babe/pkg/prompt"
+)
+
+var (
+	paperOut   string
+	restoreOut string
+
+	// Key sources for 'key --file/--env/--command'
+	keyFileSource    string
+	keyEnvSource     string
+	keyCommandSource string
+)
+
+var keyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Set, back up and restore the encryption key",
+	Long: `Set the default key from a file, an environment variable or a command such as a
+password manager, or export and rebuild the key with a paper backup.
+
+The config file stores where the key comes from (e.g. command:pass show team/t2b),
+never the key itself. The command runs once per invocation and its output is
+captured.`,
+	Example: `  text2babe key --file ~/.config/text2babe/team.key
+  text2babe key --env T2B_PASSWORD
+  text2babe key --command "pass show team/t2b"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ref, err := keyRefFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		if ref == "" {
+			if jsonOutput {
+				// Help text would end up where the JSON document belongs
+				return usageError(fmt.Errorf("key needs --file, --env or --command, or a subcommand (backup, restore)"))
+			}
+			return cmd.Help()
+		}
+		if err := setKeyRef(ref, config.SourceFlag); err != nil {
+			return err
+		}
+		if err := cfg.SaveSetting("key"); err != nil {
+			return fmt.Errorf("failed to update config file: %w", err)
+		}
+		fmt.Fprintln(statusOut, style.Info.Sprint("Saved as the key for later runs"))
+		if jsonOutput {
+			return printJSON(map[string]string{"key": ref, "fingerprint": cfg.GetKeyFingerprint()})
+		}
+		return nil
+	},
+}
+
+var keyBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Write a printable paper backup of the key",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := writePaperBackup(paperOut); err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(map[string]string{"file": paperOut, "fingerprint": cfg.GetKeyFingerprint()})
+		}
+		return nil
+	},
+}
+
+var keyRestoreCmd = &cobra.Command{
+	Use:   "restore [file]",
+	Short: "Rebuild a key from typed paper backup lines",
+	Long:  "Read the numbered backup lines (or a t2b-key: string from the QR code) from a file or stdin and check them line by line.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var r io.Reader = os.Stdin
+		if len(args) == 1 {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		} else {
+			fmt.Println(style.Info.Sprint("Type the backup lines, then an empty line (or Ctrl-D):"))
+		}
+
+		var lines []string
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" && len(lines) > 0 && len(args) == 0 {
+				break
+			}
+			lines = append(lines, line)
+		}
+
+		key, err := restoreKey(lines)
+		if err != nil {
+			return err
+		}
+		if restoreOut == "" {
+			fmt.Println(style.Info.Sprint("Use --out <file> to save the restored key as a key file"))
+		} else {
+			if err := os.WriteFile(restoreOut, []byte(paper.KeyString(key)+"\n"), 0o600); err != nil {
+				return err
+			}
+			fmt.Println(style.Success.Sprintf("🔑 Key written to %s", restoreOut))
+		}
+		if jsonOutput {
+			out := map[string]string{"fingerprint": fmt.Sprintf("%x...", key[:4])}
+			if restoreOut != "" {
+				out["file"] = restoreOut
+			}
+			return printJSON(out)
+		}
+		return nil
+	},
+}
+
+func init() {
+	keyBackupCmd.Flags().StringVar(&paperOut, "paper", "", "Output file (.pdf for a printable page, anything else for text)")
+	keyBackupCmd.MarkFlagRequired("paper")
+	keyRestoreCmd.Flags().StringVar(&restoreOut, "out", "", "Save the restored key to this key file")
+
+	keyCmd.Flags().StringVar(&keyFileSource, "file", "", "Read the key from a file (t2b-key: string or password)")
+	keyCmd.Flags().StringVar(&keyEnvSource, "env", "", "Read the key from an environment variable")
+	keyCmd.Flags().StringVar(&keyCommandSource, "command", "", "Read the key from a command's output")
+	keyCmd.MarkFlagsMutuallyExclusive("file", "env", "command")
+
+	keyCmd.AddCommand(keyBackupCmd)
+	keyCmd.AddCommand(keyRestoreCmd)
+}
+
+// keyRefFromFlags returns the key reference for 'key --file/--env/--command',
+// or "" when none was given
+func keyRefFromFlags(cmd *cobra.Command) (string, error) {
+	sources := []struct{ flag, kind, value string }{
+		{"file", "file", keyFileSource},
+		{"env", "env", keyEnvSource},
+		{"command", "command", keyCommandSource},
+	}
+	for _, s := range sources {
+		if !cmd.Flags().Changed(s.flag) {
+			continue
+		}
+		if s.value == "" {
+			return "", fmt.Errorf("--%s cannot be empty", s.flag)
+		}
+		return s.kind + ":" + s.value, nil
+	}
+	return "", nil
+}
+
+// keyRefFromShell parses the shell's 'key --file <path>', 'key --env <VAR>'
+// and 'key --command <command>' into a key reference
+func keyRefFromShell(parts []string) (string, error) {
+	option := strings.ToLower(strings.TrimLeft(parts[1], "-"))
+	value := strings.TrimSpace(strings.Join(parts[2:], " "))
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	switch option {
+	case "file", "env", "command":
+		if value == "" {
+			return "", fmt.Errorf("usage: key --file <path> | key --env <VAR> | key --command <command>")
+		}
+		return option + ":" + value, nil
+	}
+	return "", fmt.Errorf("unknown key option: %s (use --file, --env or --command)", parts[1])
+}
+
+// setKeyRef loads the key from a reference and confirms it
+func setKeyRef(ref string, src config.Source) error {
+	msg, _, err := applySetting("key", ref, src)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(statusOut, style.Success.Sprint("✓ "+msg))
+	return nil
+}
+
+// handleKeyCommand runs the shell's key backup/restore subcommands
+func handleKeyCommand(parts []string, p *prompt.Prompt) {
+	switch strings.ToLower(parts[1]) {
+	case "backup":
+		if len(parts) < 3 {
+			fmt.Println("Usage: key backup <file.pdf|file.txt>")
+			return
+		}
+		if err := writePaperBackup(strings.Join(parts[2:], " ")); err != nil {
+			fmt.Println(style.ErrorMsg(err))
+		}
+	case "restore":
+		var lines []string
+		if len(parts) >= 3 {
+			data, err := os.ReadFile(strings.Join(parts[2:], " "))
+			if err != nil {
+				fmt.Println(style.ErrorMsg(err))
+				return
+			}
+			lines = strings.Split(string(data), "\n")
+		} else {
+			fmt.Println(style.Info.Sprint("Type the backup lines, then an empty line:"))
+			for n := 1; ; n++ {
+				p.SetPrompt(fmt.Sprintf("line %d> ", n))
+				line, err := p.ReadLine()
+				if err != nil || line == "" || line == "exit" {
+					break
+				}
+				lines = append(lines, line)
+			}
+			p.UpdatePrompt(cfg.Mode)
+		}
+
+		key, err := restoreKey(lines)
+		if err != nil {
+			fmt.Println(style.ErrorMsg(err))
+			return
+		}
+		cfg.SetRawKey(key, "paper backup")
+		cfg.MarkSet("key", config.SourceShell)
+		fmt.Println(style.Success.Sprint("✓ Encryption key restored"))
+	}
+}
+
+// writePaperBackup saves the current key as a PDF or text backup sheet
+func writePaperBackup(path string) error {
+	if err := cfg.LoadKey(); err != nil {
+		return err
+	}
+	if cfg.IsDefaultKey() {
+		fmt.Println(style.WarningMsg("This is the default key; set your own first with 'key' in the shell (hidden input) or 'text2babe key --file/--env/--command'"))
+	}
+
+	sheet := &paper.Sheet{Key: cfg.Key, Fingerprint: cfg.GetKeyFingerprint(), Created: time.Now()}
+	var data []byte
+	if strings.EqualFold(filepath.Ext(path), ".pdf") {
+		pdf, err := sheet.PDF()
+		if err != nil {
+			return err
+		}
+		data = pdf
+	} else {
+		text, err := sheet.Text()
+		if err != nil {
+			return err
+		}
+		data = []byte(text)
+	}
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Println(style.Success.Sprintf("🖨  Paper backup written to %s", path))
+	fmt.Println(style.Info.Sprint("Print it, check it with 'key restore', then delete the file"))
+	return nil
+}
+
+// restoreKey parses backup lines. For a typo it shows the line to compare
+// and returns an error naming it.
+func restoreKey(lines []string) ([]byte, error) {
+	key, err := paper.ParseLines(lines)
+	var lineErr *paper.LineError
+	if errors.As(err, &lineErr) {
+		fmt.Printf("  %s\n", style.Gray.Sprint(lineErr.Text))
+		fmt.Println(style.Info.Sprintf("Compare line %d with the sheet; letters are case-insensitive and spaces don't matter", lineErr.Line))
+		return nil, fmt.Errorf("line %d has a typo", lineErr.Line)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := fmt.Sprintf("%x...", key[:4])
+	fmt.Println(style.Success.Sprintf("✓ Backup verified - key fingerprint %s", fingerprint))
+	return key, nil
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"doc0x1/text2babe/internal/config"
+	"doc0x1/text2babe/internal/recipe"
+	"doc0x1/text2babe/internal/style"
+	"doc0x1/text2babe/pkg/prompt"
+)
+
+// allowDefaultKey is bound to --allow-default-key
+var allowDefaultKey bool
+
+// checkKey refuses to encrypt, and so to send to Discord, with the default
+// key unless --allow-default-key was given. Set always for commands that
+// encrypt whatever the encryption setting is.
+func checkKey(always bool) error {
+	if !cfg.IsDefaultKey() || allowDefaultKey {
+		return nil
+	}
+	encrypts := always || cfg.UseEncryption
+	if !encrypts {
+		steps, err := activeRecipe()
+		encrypts = err == nil && recipe.Encrypts(steps)
+	}
+	if !encrypts {
+		return nil
+	}
+	return fmt.Errorf("%w; set a key with 'key' in the shell, 'text2babe key --file/--env/--command' or --key-file, or pass --allow-default-key", config.ErrDefaultKey)
+}
+
+// offerKeySetup runs when the shell starts for the first time with the
+// default key, and offers to replace it
+func offerKeySetup(p *prompt.Prompt) {
+	if !config.FirstRun() || !cfg.IsDefaultKey() || !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return
+	}
+	defer func() {
+		if err := config.EnsureFile(); err != nil {
+			fmt.Println(style.WarningMsg("could not create the config file: " + err.Error()))
+		}
+	}()
+
+	fmt.Println(style.Section("🔑 Key Setup"))
+	fmt.Println(style.Warning.Sprint("No key is set up yet. The built-in default key can be derived by anyone with the source code."))
+	fmt.Println(style.Command("g", "Generate a random key and save it to a key file (recommended)"))
+	fmt.Println(style.Command("p", "Type a password for this session"))
+	fmt.Println(style.Command("s", "Skip; encryption refuses the default key until you set one"))
+	fmt.Println()
+
+	p.SetPrompt("Choice [g/p/s]: ")
+	choice, _ := p.ReadLine()
+	p.UpdatePrompt(cfg.Mode)
+
+	switch strings.ToLower(choice) {
+	case "", "g":
+		path, err := cfg.CreateKeyFile()
+		if err == nil {
+			cfg.MarkSet("key", config.SourceShell)
+			err = cfg.SaveSetting("key")
+		}
+		if err != nil {
+			fmt.Println(style.ErrorMsg(err))
+			return
+		}
+		fmt.Println(style.Success.Sprintf("🔑 Key saved to %s (fingerprint: %s)", path, cfg.GetKeyFingerprint()))
+		fmt.Println(style.Info.Sprint("Back it up with 'key backup key.pdf'; without it, messages can't be decrypted"))
+	case "p":
+		password, err := readNewPassword(p)
+		if err != nil {
+			fmt.Println(style.ErrorMsg(err))
+			return
+		}
+		if password == "" {
+			fmt.Println(style.Info.Sprint("Key unchanged"))
+			return
+		}
+		setPassword(password)
+		fmt.Println(style.Info.Sprint("Passwords are never saved; type 'key' next session, or use key --file/--env/--command"))
+	default:
+		fmt.Println(style.Info.Sprint("Skipped. Type 'key' any time to set one."))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"doc0x1/text2babe/internal/crypto"
+	"doc0x1/text2babe/internal/style"
+)
+
+const magicResults = 5
+
+var magicAES bool
+
+var magicCmd = &cobra.Command{
+	Use:   "magic [data]",
+	Short: "Recursively decode layered encodings",
+	Long:  "Peel nested encodings (binary, hex, base64), gzip/zlib compression and optionally the encryption layer, and show the chains that produce readable output.",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showMagicResults(strings.Join(args, " "), magicAES)
+	},
+}
+
+func init() {
+	magicCmd.Flags().BoolVar(&magicAES, "aes", false, "Also try decrypting with the current key and cipher")
+}
+
+func handleMagicCommand(parts []string) {
+	useAES := len(parts) >= 2 && parts[1] == "--aes"
+	if useAES {
+		parts = parts[1:]
+	}
+	if len(parts) < 2 {
+		fmt.Println("Usage: magic [--aes] <data>")
+		return
+	}
+	if err := showMagicResults(strings.Join(parts[1:], " "), useAES); err != nil {
+		fmt.Println(style.ErrorMsg(err))
+	}
+}
+
+// magicJSON is the --json form of a magic decoding chain
+type magicJSON struct {
+	Steps     []string `json:"steps"`
+	Printable float64  `json:"printable"`
+	Entropy   float64  `json:"entropy"`
+	Result    string   `json:"result"`
+}
+
+func showMagicResults(data string, useAES bool) error {
+	var key []byte
+	if useAES {
+		if err := cfg.LoadKey(); err != nil {
+			return err
+		}
+		key = cfg.Key
+	}
+
+	results := crypto.Magic(data, cfg.Cipher, key)
+	if len(results) == 0 {
+		return fmt.Errorf("no decoding chain produced readable output")
+	}
+	if len(results) > magicResults {
+		results = results[:magicResults]
+	}
+
+	if jsonOutput {
+		out := make([]magicJSON, len(results))
+		for i, r := range results {
+			out[i] = magicJSON{Steps: r.Steps, Printable: r.Printable, Entropy: r.Entropy, Result: printable(r.Data)}
+		}
+		return printJSON(map[string][]magicJSON{"results": out})
+	}
+
+	fmt.Println(style.Section("✨ Magic Results:"))
+	for i, r := range results {
+		fmt.Printf("  %s %s %s\n",
+			style.Accent.Sprintf("%d.", i+1),
+			style.Cyan.Sprint(strings.Join(r.Steps, " → ")),
+			style.Gray.Sprintf("(printable %.0f%%, entropy %.2f)", r.Printable*100, r.Entropy))
+		fmt.Printf("     %s\n", style.White.Sprint(printable(r.Data)))
+	}
+	fmt.Println()
+	return nil
+}
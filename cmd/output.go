@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+
+	"doc0x1/text2babe/internal/config"
+	"doc0x1/text2babe/internal/crypto"
+	"doc0x1/text2babe/internal/discord"
+)
+
+// Exit codes, stable so scripts can branch on them
+const (
+	exitError      = 1 // anything not listed below
+	exitUsage      = 2 // bad flags, arguments or settings
+	exitAuth       = 3 // wrong key or tampered ciphertext
+	exitFormat     = 4 // input format could not be determined, or is truncated
+	exitDiscord    = 5 // Discord send failed
+	exitDefaultKey = 6 // refused to encrypt with the default key
+)
+
+// jsonOutput is bound to --json
+var jsonOutput bool
+
+// jsonOut receives the JSON document. With --json everything else that is
+// printed goes to stderr.
+var jsonOut io.Writer = os.Stdout
+
+// commandStarted is set once flags and arguments are validated, so errors
+// returned after it are failures rather than usage mistakes
+var commandStarted bool
+
+// cmdError is a failed command with a stable code for --json and the exit status
+type cmdError struct {
+	Code     string
+	Exit     int
+	Err      error
+	reported bool // already shown to the user; only set the exit status
+}
+
+func (e *cmdError) Error() string { return e.Err.Error() }
+func (e *cmdError) Unwrap() error { return e.Err }
+
+// classifyError picks the code and exit status for an error
+func classifyError(err error) *cmdError {
+	var ce *cmdError
+	var apiErr *discord.DiscordAPIError
+	switch {
+	case errors.As(err, &ce):
+		return ce
+	case !commandStarted:
+		return usageError(err)
+	case errors.Is(err, crypto.ErrAuthenticationFailed):
+		return &cmdError{Code: "auth_failed", Exit: exitAuth, Err: err}
+	case errors.Is(err, crypto.ErrUnknownFormat):
+		return &cmdError{Code: "unknown_format", Exit: exitFormat, Err: err}
+	case errors.Is(err, crypto.ErrCiphertextTooShort):
+		return &cmdError{Code: "ciphertext_too_short", Exit: exitFormat, Err: err}
+	case errors.Is(err, config.ErrDefaultKey):
+		return &cmdError{Code: "default_key", Exit: exitDefaultKey, Err: err}
+	case errors.Is(err, discord.ErrNotConfigured), errors.As(err, &apiErr):
+		return discordError(err)
+	}
+	return &cmdError{Code: "error", Exit: exitError, Err: err}
+}
+
+func usageError(err error) *cmdError {
+	return &cmdError{Code: "usage", Exit: exitUsage, Err: err}
+}
+
+func discordError(err error) *cmdError {
+	return &cmdError{Code: "discord_failed", Exit: exitDiscord, Err: err}
+}
+
+// discordErrorCode returns the Discord API error code in err, or 0
+func discordErrorCode(err error) int {
+	var apiErr *discord.DiscordAPIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return 0
+}
+
+// setupJSON moves all human-readable output to stderr so stdout carries
+// only the JSON document
+func setupJSON() {
+	jsonOut = os.Stdout
+	os.Stdout = os.Stderr
+	statusOut = os.Stderr
+	color.NoColor = true
+	cfg.Clipboard = false
+}
+
+// printJSON writes v as an indented JSON document
+func printJSON(v any) error {
+	enc := json.NewEncoder(jsonOut)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+// jsonError is the --json form of a failure
+type jsonError struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// exitWithError reports err and exits with its status
+func exitWithError(err error) {
+	ce := classifyError(err)
+	if ce.reported {
+		os.Exit(ce.Exit)
+	}
+	if jsonOutput {
+		var out jsonError
+		out.Error.Code = ce.Code
+		out.Error.Message = ce.Error()
+		printJSON(out)
+	} else {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", ce)
+	}
+	os.Exit(ce.Exit)
+}
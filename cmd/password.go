@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"doc0x1/text2babe/internal/config"
+	"doc0x1/text2babe/internal/style"
+	"doc0x1/text2babe/pkg/prompt"
+)
+
+// commonPasswords are rated very weak whatever their length
+var commonPasswords = []string{
+	"password", "passw0rd", "123456", "12345678", "qwerty", "letmein",
+	"iloveyou", "admin", "welcome", "monkey", "dragon", "default-password",
+}
+
+// passwordStrength estimates the entropy of a password in bits from its
+// length and the character classes it uses
+func passwordStrength(password string) float64 {
+	lower := strings.ToLower(password)
+	for _, common := range commonPasswords {
+		if strings.Contains(lower, common) {
+			return 0
+		}
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol, hasOther bool
+	distinct := map[rune]bool{}
+	for _, r := range password {
+		distinct[r] = true
+		switch {
+		case r > unicode.MaxASCII:
+			hasOther = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+
+	pool := 0
+	for _, class := range []struct {
+		present bool
+		size    int
+	}{{hasLower, 26}, {hasUpper, 26}, {hasDigit, 10}, {hasSymbol, 33}, {hasOther, 100}} {
+		if class.present {
+			pool += class.size
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+	// Repeated characters add little, so count mostly distinct ones
+	length := float64(len(distinct)) + float64(len([]rune(password))-len(distinct))/4
+	return length * math.Log2(float64(pool))
+}
+
+// strengthMeter renders a password's strength as a bar with a label
+func strengthMeter(password string) string {
+	bits := passwordStrength(password)
+	levels := []struct {
+		below float64
+		label string
+		color func(a ...interface{}) string
+	}{
+		{28, "very weak", style.Error.Sprint},
+		{36, "weak", style.Warning.Sprint},
+		{60, "fair", style.Warning.Sprint},
+		{80, "strong", style.Success.Sprint},
+		{math.Inf(1), "very strong", style.Success.Sprint},
+	}
+	filled := int(math.Min(bits/10, 10))
+	for _, level := range levels {
+		if bits < level.below {
+			bar := strings.Repeat("█", filled) + strings.Repeat("░", 10-filled)
+			return fmt.Sprintf("%s %s", level.color(bar), level.color(level.label))
+		}
+	}
+	return ""
+}
+
+// readNewPassword prompts twice for a password with masked input and shows
+// its strength. It returns "" if the user cancels with empty input.
+func readNewPassword(p *prompt.Prompt) (string, error) {
+	password, err := p.ReadPassword("New password: ")
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	if password == "" {
+		return "", nil
+	}
+	fmt.Println(style.Setting("Strength", strengthMeter(password)))
+
+	confirm, err := p.ReadPassword("Confirm password: ")
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	if confirm != password {
+		return "", fmt.Errorf("passwords don't match; key unchanged")
+	}
+	return password, nil
+}
+
+// setPassword makes password the key for this session
+func setPassword(password string) {
+	cfg.SetKey(password)
+	cfg.MarkSet("key", config.SourceShell)
+	fmt.Printf("%s\n", style.Success.Sprint("✓ Encryption key updated"))
+	fmt.Printf("%s %s\n", style.Info.Sprint("Key fingerprint:"), cfg.GetKeyFingerprint())
+	if len([]rune(password)) < 8 || passwordStrength(password) < 36 {
+		fmt.Printf("%s\n", style.Warning.Sprint("⚠ Consider using a longer password for better security"))
+	}
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+
+	"doc0x1/text2babe/internal/style"
+)
+
+// statusOut receives status messages. When stdout is piped it switches to
+// stderr, so stdout carries only the result.
+var statusOut io.Writer = os.Stdout
+
+// pipeMode is set when stdout isn't a terminal
+var pipeMode bool
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// setupPipeMode switches to plain output with no colors or clipboard when
+// stdout isn't a terminal
+func setupPipeMode() {
+	if isTerminal(os.Stdout) {
+		return
+	}
+	pipeMode = true
+	statusOut = os.Stderr
+	color.NoColor = true
+	cfg.Clipboard = false
+}
+
+// readArgsOrStdin joins the positional args, or reads all of stdin when
+// there are none or the only one is "-". Empty stdin is a usage error.
+func readArgsOrStdin(args []string) (data string, fromStdin bool, err error) {
+	if len(args) > 0 && (len(args) != 1 || args[0] != "-") {
+		return strings.Join(args, " "), false, nil
+	}
+	if isTerminal(os.Stdin) {
+		fmt.Fprintln(os.Stderr, style.Gray.Sprint("Reading from stdin; press Ctrl-D to finish"))
+	}
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", true, fmt.Errorf("failed to read stdin: %w", err)
+	}
+	if len(b) == 0 {
+		return "", true, usageError(fmt.Errorf("no input: give the data as an argument or pipe it on stdin"))
+	}
+	return string(b), true, nil
+}
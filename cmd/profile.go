@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"doc0x1/text2babe/internal/config"
+	"doc0x1/text2babe/internal/style"
+	"doc0x1/text2babe/pkg/prompt"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage settings profiles",
+	Long: `A profile bundles the output format, encryption on/off, cipher, key reference,
+Discord channel and auto-send setting under one name. Profiles are stored in
+the config file; 'profile use' makes one the default for later runs.`,
+}
+
+var profileCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Save the current settings as a profile",
+	Long:  "Save the current settings, including any flags given, as a profile.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		values, err := createProfile(args[0])
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(map[string]any{"name": args[0], "settings": values})
+		}
+		return nil
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Switch to a profile for this and later runs",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := useProfile(args[0], config.SourceFile); err != nil {
+			return err
+		}
+		if err := cfg.SaveActiveProfile(); err != nil {
+			return fmt.Errorf("failed to update config file: %w", err)
+		}
+		if jsonOutput {
+			return printJSON(map[string]string{"active": args[0]})
+		}
+		return nil
+	},
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stored profiles",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if jsonOutput {
+			profiles := map[string]map[string]string{}
+			for _, name := range config.ProfileNames() {
+				values, err := config.Profile(name)
+				if err != nil {
+					return err
+				}
+				profiles[name] = values
+			}
+			return printJSON(struct {
+				Profiles map[string]map[string]string `json:"profiles"`
+				Active   string                       `json:"active,omitempty"`
+			}{profiles, cfg.Profile})
+		}
+		listProfiles()
+		return nil
+	},
+}
+
+var profileDeleteCmd = &cobra.Command{
+	Use:     "delete <name>",
+	Aliases: []string{"remove"},
+	Short:   "Delete a stored profile",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := deleteProfile(args[0]); err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(map[string]string{"removed": args[0]})
+		}
+		return nil
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileCreateCmd)
+	profileCmd.AddCommand(profileUseCmd)
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileDeleteCmd)
+}
+
+func handleProfileCommand(parts []string, p *prompt.Prompt) {
+	if len(parts) < 2 {
+		listProfiles()
+		return
+	}
+	var err error
+	switch strings.ToLower(parts[1]) {
+	case "create", "save":
+		if len(parts) != 3 {
+			fmt.Println("Usage: profile create <name>")
+			return
+		}
+		_, err = createProfile(parts[2])
+	case "use":
+		if len(parts) != 3 {
+			fmt.Println("Usage: profile use <name>")
+			return
+		}
+		err = useProfile(parts[2], config.SourceShell)
+		p.UpdatePrompt(cfg.Mode)
+	case "list", "ls":
+		listProfiles()
+	case "delete", "remove", "rm":
+		if len(parts) != 3 {
+			fmt.Println("Usage: profile delete <name>")
+			return
+		}
+		err = deleteProfile(parts[2])
+		p.UpdatePrompt(cfg.Mode)
+	default:
+		err = fmt.Errorf("unknown profile command: %s", parts[1])
+	}
+	if err != nil {
+		fmt.Println(style.ErrorMsg(err))
+	}
+}
+
+func createProfile(name string) (map[string]string, error) {
+	values, err := cfg.CreateProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(statusOut, style.Success.Sprintf("✓ Profile %s: %s", name, profileSummary(values)))
+	if !cfg.IsDefaultKey() && cfg.KeyRef == "" {
+		fmt.Fprintln(statusOut, style.WarningMsg("the key was entered as a password and is not part of the profile; use --key-file or 'set key file:<path>' to include it"))
+	}
+	return values, nil
+}
+
+// useProfile applies a profile; settings that could not be applied are
+// reported as warnings since the rest of the profile is in effect
+func useProfile(name string, src config.Source) error {
+	err := cfg.UseProfile(name, src)
+	if cfg.Profile != name {
+		return err
+	}
+	fmt.Fprintln(statusOut, style.Success.Sprintf("✓ Using profile %s", name))
+	if err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintln(statusOut, style.WarningMsg(line))
+		}
+	}
+	return nil
+}
+
+func deleteProfile(name string) error {
+	if err := cfg.DeleteProfile(name); err != nil {
+		return err
+	}
+	fmt.Fprintln(statusOut, style.Success.Sprintf("✓ Profile %s removed", name))
+	return nil
+}
+
+func listProfiles() {
+	names := config.ProfileNames()
+	if len(names) == 0 {
+		fmt.Println(style.Info.Sprint("No profiles defined. Save the current settings with: profile create <name>"))
+		return
+	}
+	fmt.Println(style.Section("👤 Profiles:"))
+	for _, name := range names {
+		values, err := config.Profile(name)
+		if err != nil {
+			continue
+		}
+		label := name
+		if name == cfg.Profile {
+			label += " *"
+		}
+		fmt.Println(style.Setting(label, profileSummary(values)))
+	}
+	fmt.Println()
+}
+
+// profileSummary lists a profile's settings in application order
+func profileSummary(values map[string]string) string {
+	var fields []string
+	for _, setting := range config.ProfileSettings {
+		if value, ok := values[setting]; ok {
+			fields = append(fields, setting+"="+value)
+		}
+	}
+	return strings.Join(fields, ", ")
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"doc0x1/text2babe/internal/qr"
+	"doc0x1/text2babe/internal/style"
+)
+
+// Keep terminal codes narrow enough to fit a normal window
+const terminalQRVersion = 15
+
+// showQR prints result as one or more terminal QR codes
+func showQR(result string) error {
+	codes, err := qr.Sequence(result, terminalQRVersion)
+	if err != nil {
+		return err
+	}
+	for i, code := range codes {
+		if len(codes) > 1 {
+			fmt.Fprintln(statusOut, style.Info.Sprintf("QR %d/%d:", i+1, len(codes)))
+		}
+		fmt.Fprint(statusOut, code.Terminal())
+	}
+	return nil
+}
+
+// writeQRFiles writes result as PNG files. Multi-part sequences get a
+// numbered suffix before the extension (out-1.png, out-2.png, ...).
+func writeQRFiles(result, path string) ([]string, error) {
+	codes, err := qr.Sequence(result, qr.MaxVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	var written []string
+	for i, code := range codes {
+		name := path
+		if len(codes) > 1 {
+			name = fmt.Sprintf("%s-%d%s", base, i+1, ext)
+		}
+		f, err := os.Create(name)
+		if err != nil {
+			return written, err
+		}
+		err = code.WritePNG(f, 8)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		written = append(written, name)
+	}
+	return written, nil
+}
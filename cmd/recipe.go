@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"doc0x1/text2babe/internal/crypto"
+	"doc0x1/text2babe/internal/recipe"
+	"doc0x1/text2babe/internal/style"
+)
+
+// recipeName is bound to --recipe on both encrypt and decrypt
+var recipeName string
+
+var recipeCmd = &cobra.Command{
+	Use:   "recipe",
+	Short: "Manage transform pipeline recipes",
+	Long: `Recipes are named transform chains such as "gzip | aes-gcm | base58 | wrap 80".
+They are stored in the config file and applied with 'encrypt --recipe <name>'.`,
+}
+
+var recipeAddCmd = &cobra.Command{
+	Use:   "add <name>: <step> | <step> ...",
+	Short: "Add or replace a recipe",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, spec, err := addRecipe(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(map[string]string{"name": name, "recipe": spec})
+		}
+		return nil
+	},
+}
+
+var recipeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stored recipes",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if jsonOutput {
+			return printJSON(struct {
+				Recipes map[string]string `json:"recipes"`
+				Active  string            `json:"active,omitempty"`
+			}{cfg.Recipes, cfg.Recipe})
+		}
+		listRecipes()
+		return nil
+	},
+}
+
+var recipeRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Delete a stored recipe",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := removeRecipe(args[0]); err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(map[string]string{"removed": args[0]})
+		}
+		return nil
+	},
+}
+
+func init() {
+	recipeCmd.AddCommand(recipeAddCmd)
+	recipeCmd.AddCommand(recipeListCmd)
+	recipeCmd.AddCommand(recipeRemoveCmd)
+}
+
+func handleRecipeCommand(parts []string) {
+	if len(parts) < 2 {
+		listRecipes()
+		return
+	}
+	var err error
+	switch strings.ToLower(parts[1]) {
+	case "add":
+		if len(parts) < 3 {
+			fmt.Println("Usage: recipe add <name>: <step> | <step> ...")
+			return
+		}
+		_, _, err = addRecipe(strings.Join(parts[2:], " "))
+	case "list", "ls":
+		listRecipes()
+	case "remove", "rm", "delete":
+		if len(parts) < 3 {
+			fmt.Println("Usage: recipe remove <name>")
+			return
+		}
+		err = removeRecipe(parts[2])
+	default:
+		err = fmt.Errorf("unknown recipe command: %s", parts[1])
+	}
+	if err != nil {
+		fmt.Println(style.ErrorMsg(err))
+	}
+}
+
+// addRecipe accepts "name: steps" or "name steps" and returns the stored recipe
+func addRecipe(input string) (name, spec string, err error) {
+	name, spec, ok := strings.Cut(input, ":")
+	if !ok || strings.ContainsAny(strings.TrimSpace(name), " \t") {
+		name, spec, _ = strings.Cut(strings.TrimSpace(input), " ")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || strings.TrimSpace(spec) == "" {
+		return "", "", usageError(fmt.Errorf("a recipe needs a name and at least one step: recipe add <name>: <step> | <step> ..."))
+	}
+
+	steps, err := recipe.Parse(spec)
+	if err != nil {
+		return "", "", err
+	}
+
+	spec = recipe.String(steps)
+	cfg.SetRecipe(name, spec)
+	if err := cfg.SaveRecipes(); err != nil {
+		return "", "", fmt.Errorf("recipe added for this session only: %w", err)
+	}
+	fmt.Println(style.Success.Sprintf("✓ Recipe %s: %s", name, spec))
+	return name, spec, nil
+}
+
+func listRecipes() {
+	names := cfg.RecipeNames()
+	if len(names) == 0 {
+		fmt.Println(style.Info.Sprint("No recipes defined. Add one with: recipe add <name>: gzip | aes-gcm | base58"))
+		return
+	}
+	fmt.Println(style.Section("🧪 Recipes:"))
+	for _, name := range names {
+		label := name
+		if name == cfg.Recipe {
+			label += " *"
+		}
+		fmt.Println(style.Setting(label, cfg.Recipes[name]))
+	}
+	fmt.Println()
+}
+
+func removeRecipe(name string) error {
+	if !cfg.DeleteRecipe(name) {
+		return fmt.Errorf("no recipe named %s", name)
+	}
+	if err := cfg.SaveRecipes(); err != nil {
+		return fmt.Errorf("failed to update config file: %w", err)
+	}
+	fmt.Println(style.Success.Sprintf("✓ Recipe %s removed", name))
+	return nil
+}
+
+// activeRecipe returns the parsed steps of the selected recipe, if any
+func activeRecipe() ([]recipe.Step, error) {
+	if cfg.Recipe == "" {
+		return nil, nil
+	}
+	spec, ok := cfg.Recipes[cfg.Recipe]
+	if !ok {
+		return nil, fmt.Errorf("no recipe named %s", cfg.Recipe)
+	}
+	return recipe.Parse(spec)
+}
+
+// encryptInput encrypts with the active recipe, or the normal settings
+func encryptInput(data string) (string, error) {
+	steps, err := activeRecipe()
+	if err != nil || steps == nil {
+		if err != nil {
+			return "", err
+		}
+		return crypto.EncryptData(data, cfg)
+	}
+
+	input, err := crypto.ReadInput(data, cfg)
+	if err != nil {
+		return "", err
+	}
+	if recipe.Encrypts(steps) {
+		if err := cfg.LoadKey(); err != nil {
+			return "", err
+		}
+	}
+	out, err := recipe.Apply(steps, input, cfg.Key)
+	if err != nil {
+		return "", err
+	}
+	if !recipe.TextOutput(steps) {
+		return crypto.EncodeOutput(out, cfg.OutputMode)
+	}
+	return string(out), nil
+}
+
+// decryptInput inverts the active recipe, or decrypts with the normal settings
+func decryptInput(data string) (*crypto.Decrypted, error) {
+	steps, err := activeRecipe()
+	if err != nil || steps == nil {
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Decrypt(data, cfg)
+	}
+
+	input := []byte(data)
+	if !recipe.TextOutput(steps) {
+		// The recipe ended in raw bytes, so undo the output format first
+		if cfg.InputFormat != "auto" {
+			input, err = crypto.DecodeFormat(data, cfg.InputFormat)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			candidates := crypto.DetectFormat(data, true)
+			if len(candidates) == 0 {
+				return nil, crypto.ErrUnknownFormat
+			}
+			input = candidates[0].Bytes
+		}
+	}
+
+	if recipe.Encrypts(steps) {
+		if err := cfg.LoadKey(); err != nil {
+			return nil, err
+		}
+	}
+	out, err := recipe.Invert(steps, input, cfg.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &crypto.Decrypted{Plaintext: out, Format: "recipe:" + cfg.Recipe}, nil
+}
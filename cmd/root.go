@@ -1,16 +1,18 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 
-	"github.com/atotto/clipboard"
 	"github.com/spf13/cobra"
 
+	"doc0x1/text2babe/internal/classic"
 	"doc0x1/text2babe/internal/config"
 	"doc0x1/text2babe/internal/crypto"
+	"doc0x1/text2babe/internal/discord"
 	"doc0x1/text2babe/internal/style"
 	"doc0x1/text2babe/pkg/prompt"
 )
@@ -22,13 +24,13 @@ var rootCmd = &cobra.Command{
 	Short: "🔐 Text2Babe - Encryption/Decryption CLI Tool",
 	Long: `Text2Babe is a CLI tool for encrypting and decrypting text and binary data.
 It features an interactive shell with zsh-style prompts and easy toggling between modes.`,
-	Run: runInteractiveShell,
+	Run:           runInteractiveShell,
+	SilenceErrors: true, // Execute prints the error once
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		exitWithError(err)
 	}
 }
 
@@ -39,6 +41,13 @@ func init() {
 	rootCmd.AddCommand(decryptCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(shellCmd)
+	rootCmd.AddCommand(stegoCmd)
+	rootCmd.AddCommand(analyzeCmd)
+	rootCmd.AddCommand(detectCmd)
+	rootCmd.AddCommand(magicCmd)
+	rootCmd.AddCommand(recipeCmd)
+	rootCmd.AddCommand(profileCmd)
+	rootCmd.AddCommand(keyCmd)
 }
 
 func runInteractiveShell(cmd *cobra.Command, args []string) {
@@ -47,12 +56,16 @@ func runInteractiveShell(cmd *cobra.Command, args []string) {
 	fmt.Printf("%s\n", style.Gray.Sprint("Type 'help' for available commands or 'exit' to quit."))
 	fmt.Println()
 
+	prompt.SetRecipeNames(cfg.RecipeNames)
+	prompt.SetProfileNames(config.ProfileNames)
+	prompt.SetProfileName(func() string { return cfg.Profile })
 	p, err := prompt.New()
 	if err != nil {
 		fmt.Printf("Error creating prompt: %v\n", err)
 		return
 	}
 	defer p.Close()
+	offerKeySetup(p)
 
 	for {
 		line, err := p.ReadLine()
@@ -68,11 +81,28 @@ func runInteractiveShell(cmd *cobra.Command, args []string) {
 		if line == "" {
 			continue
 		}
+		if !hasSecret(line) {
+			p.AddHistory(line)
+		}
 
 		handleInteractiveCommand(line, p)
 	}
 }
 
+// hasSecret reports whether a shell line carries a password, as in
+// 'key <password>', and so must stay out of the history
+func hasSecret(line string) bool {
+	parts := strings.Fields(line)
+	if len(parts) < 2 || !strings.EqualFold(parts[0], "key") {
+		return false
+	}
+	switch strings.ToLower(parts[1]) {
+	case "backup", "restore", "--prompt":
+		return false
+	}
+	return !strings.HasPrefix(parts[1], "--")
+}
+
 func handleInteractiveCommand(input string, p *prompt.Prompt) {
 	parts := strings.Fields(input)
 	if len(parts) == 0 {
@@ -85,7 +115,11 @@ func handleInteractiveCommand(input string, p *prompt.Prompt) {
 	case "help", "h":
 		showHelp()
 	case "settings", "config":
-		showSettings()
+		if len(parts) >= 2 {
+			handleConfigCommand(parts[1], p)
+		} else {
+			showSettings()
+		}
 	case "mode", "m":
 		if len(parts) >= 2 {
 			handleModeCommand(parts[1], p)
@@ -93,27 +127,35 @@ func handleInteractiveCommand(input string, p *prompt.Prompt) {
 			fmt.Printf("%s\n", style.Info.Sprintf("Current mode: %s", cfg.Mode))
 		}
 	case "set":
-		if len(parts) >= 3 {
+		if len(parts) >= 3 && strings.ToLower(parts[1]) == "stego" {
+			// Cover text may contain spaces
+			handleSet(parts[1], strings.Join(parts[2:], " "), p)
+		} else if len(parts) >= 3 {
 			handleSet(parts[1], parts[2], p)
 		} else {
 			fmt.Println("Usage: set <setting> <value>")
 		}
 	case "encrypt", "e":
 		if len(parts) >= 2 {
+			if err := checkKey(false); err != nil {
+				fmt.Println(style.ErrorMsg(err))
+				break
+			}
 			data := strings.Join(parts[1:], " ")
-			result, err := crypto.EncryptData(data, cfg)
+			result, err := encryptInput(data)
 			if err != nil {
 				fmt.Println(style.ErrorMsg(err))
 			} else {
 				fmt.Println(style.Result("Encrypted", result))
 
-				// Copy to clipboard
-				if err := clipboard.WriteAll(result); err != nil {
-					fmt.Println(style.WarningMsg("Failed to copy to clipboard: " + err.Error()))
-				} else {
-					fmt.Println(style.SuccessWithClipboard("Encrypted"))
+				if cfg.ShowQR {
+					if err := showQR(result); err != nil {
+						fmt.Println(style.WarningMsg("Failed to render QR code: " + err.Error()))
+					}
 				}
 
+				copyResult("Encrypted", result)
+
 				// Send to Discord if enabled
 				discord := cfg.GetDiscord()
 				if cfg.SendToDiscord && discord.IsEnabled() {
@@ -130,20 +172,25 @@ func handleInteractiveCommand(input string, p *prompt.Prompt) {
 	case "decrypt", "d":
 		if len(parts) >= 2 {
 			data := strings.Join(parts[1:], " ")
-			result, err := crypto.DecryptData(data, cfg)
+			noteAmbiguousInput(data)
+			result, err := decryptInput(data)
 			if err != nil {
 				fmt.Println(style.ErrorMsg(err))
 			} else {
-				fmt.Println(style.Result("Decrypted", result))
-				if err := clipboard.WriteAll(result); err != nil {
-					fmt.Println(style.WarningMsg("Failed to copy to clipboard: " + err.Error()))
-				} else {
-					fmt.Println(style.SuccessWithClipboard("Decrypted"))
-				}
+				reportCorrections(result)
+				showDecrypted(result)
 			}
 		} else {
 			fmt.Println("Usage: decrypt <data>")
 		}
+	case "save":
+		if len(parts) < 2 {
+			fmt.Println("Usage: save <file>")
+		} else if lastDecrypted == nil {
+			fmt.Println(style.ErrorMsg(fmt.Errorf("nothing decrypted yet")))
+		} else if err := saveOutput(strings.Join(parts[1:], " "), lastDecrypted); err != nil {
+			fmt.Println(style.ErrorMsg(err))
+		}
 	case "toggle", "t":
 		if len(parts) >= 2 {
 			handleToggle(parts[1], p)
@@ -151,42 +198,60 @@ func handleInteractiveCommand(input string, p *prompt.Prompt) {
 			fmt.Println("Usage: toggle <setting>")
 		}
 	case "key":
-		if len(parts) >= 2 {
-			password := strings.Join(parts[1:], " ")
-			cfg.SetKey(password)
-			fmt.Printf("%s\n", style.Success.Sprint("✓ Encryption key updated"))
-			fmt.Printf("%s %s\n", style.Info.Sprint("Key fingerprint:"), cfg.GetKeyFingerprint())
-			if len(password) < 8 {
-				fmt.Printf("%s\n", style.Warning.Sprint("⚠ Consider using a longer password for better security"))
+		if len(parts) == 2 && strings.EqualFold(parts[1], "--prompt") {
+			parts = parts[:1] // same as a bare 'key'
+		}
+		if len(parts) >= 2 && (strings.EqualFold(parts[1], "backup") || strings.EqualFold(parts[1], "restore")) {
+			handleKeyCommand(parts, p)
+		} else if len(parts) >= 2 && strings.HasPrefix(parts[1], "--") {
+			ref, err := keyRefFromShell(parts)
+			if err == nil {
+				err = setKeyRef(ref, config.SourceShell)
+			}
+			if err != nil {
+				fmt.Println(style.ErrorMsg(err))
+			} else {
+				fmt.Println(style.Info.Sprint("Run 'config save' to keep using this key source"))
 			}
+		} else if len(parts) >= 2 {
+			// Still accepted for scripts, but the password stays on screen
+			fmt.Println(style.WarningMsg("the password is visible on screen and in your scrollback (it is kept out of the history); type 'key' alone to enter it hidden"))
+			setPassword(strings.Join(parts[1:], " "))
 		} else {
-			fmt.Println("Usage: key <password>")
+			password, err := readNewPassword(p)
+			if err != nil {
+				fmt.Println(style.ErrorMsg(err))
+			} else if password == "" {
+				fmt.Println(style.Info.Sprint("Key unchanged"))
+			} else {
+				setPassword(password)
+			}
 		}
 	case "discord":
 		if len(parts) >= 2 {
 			subCommand := strings.ToLower(parts[1])
 			switch subCommand {
 			case "test":
-				discord := cfg.GetDiscord()
-				if !discord.IsEnabled() {
-					fmt.Println(style.ErrorMsg(fmt.Errorf("discord not configured (missing DISCORD_USER_TOKEN or DISCORD_DM_ID)")))
+				client := cfg.GetDiscord()
+				if !client.IsEnabled() {
+					fmt.Println(style.ErrorMsg(discord.ErrNotConfigured))
 				} else {
 					fmt.Println(style.Info.Sprint("Testing Discord connection..."))
-					if err := discord.TestConnection(); err != nil {
+					if err := client.TestConnection(); err != nil {
 						fmt.Println(style.ErrorMsg(err))
 					} else {
 						fmt.Println(style.Success.Sprint("✓ Discord connection successful!"))
 					}
 				}
 			case "fetch", "decrypt":
-				discord := cfg.GetDiscord()
-				if !discord.IsEnabled() {
-					fmt.Println(style.ErrorMsg(fmt.Errorf("discord not configured (missing DISCORD_USER_TOKEN or DISCORD_DM_ID)")))
+				client := cfg.GetDiscord()
+				if !client.IsEnabled() {
+					fmt.Println(style.ErrorMsg(discord.ErrNotConfigured))
 					return
 				}
 
 				fmt.Println(style.Info.Sprint("Fetching last Discord message..."))
-				data, mode, err := discord.FetchAndValidateLastMessage()
+				data, mode, err := client.FetchAndValidateLastMessage()
 				if err != nil {
 					fmt.Println(style.ErrorMsg(err))
 					return
@@ -197,8 +262,8 @@ func handleInteractiveCommand(input string, p *prompt.Prompt) {
 				// Decode/decrypt the message using auto-detection
 				result, decryptErr := crypto.DecryptData(data, cfg)
 				
-				if decryptErr != nil {
-					// If that fails, try without encryption (plain encoding)
+				if errors.Is(decryptErr, crypto.ErrAuthenticationFailed) || errors.Is(decryptErr, crypto.ErrCiphertextTooShort) {
+					// Not sealed with our key; it may be plain encoding
 					oldEncryption := cfg.UseEncryption
 					cfg.UseEncryption = false
 					result, decryptErr = crypto.DecryptData(data, cfg)
@@ -222,6 +287,24 @@ func handleInteractiveCommand(input string, p *prompt.Prompt) {
 			fmt.Printf("%s: %s\n", style.Info.Sprint("Discord Status"), status)
 			fmt.Printf("%s: %s\n", style.Info.Sprint("Details"), message)
 		}
+	case "stego":
+		handleStegoCommand(parts)
+	case "analyze":
+		handleAnalyzeCommand(parts)
+	case "recipe", "recipes":
+		handleRecipeCommand(parts)
+	case "profile", "profiles":
+		handleProfileCommand(parts, p)
+	case "magic":
+		handleMagicCommand(parts)
+	case "detect":
+		if len(parts) >= 2 {
+			if err := showFormatCandidates(strings.Join(parts[1:], " ")); err != nil {
+				fmt.Println(style.ErrorMsg(err))
+			}
+		} else {
+			fmt.Println("Usage: detect <data>")
+		}
 	case "exit", "quit", "q":
 		fmt.Println("Goodbye!")
 		os.Exit(0)
@@ -233,21 +316,42 @@ func handleInteractiveCommand(input string, p *prompt.Prompt) {
 func showHelp() {
 	fmt.Println(style.Section("📖 Available Commands:"))
 	fmt.Println(style.Command("help, h", "Show this help message"))
-	fmt.Println(style.Command("settings, config", "Show current settings"))
+	fmt.Println(style.Command("settings, config", "Show current settings and where each came from"))
+	fmt.Println(style.Command("config save", "Save the current settings to the config file"))
+	fmt.Println(style.Command("config reset", "Remove saved settings and return to the defaults"))
 	fmt.Println(style.Command("mode, m [encrypt/e/decrypt/d]", "Set or show current mode"))
 	fmt.Println(style.Command("encrypt, e <data>", "Encrypt data"))
 	fmt.Println(style.Command("decrypt, d <data>", "Decrypt data"))
-	fmt.Println(style.Command("key <password>", "Set encryption key from password"))
+	fmt.Println(style.Command("save <file>", "Write the last decrypted data to a file"))
+	fmt.Println(style.Command("key", "Set the encryption key from a password typed hidden"))
+	fmt.Println(style.Command("key --file/--env/--command", "Load the key from a file, variable or command output"))
+	fmt.Println(style.Command("key backup/restore", "Print a paper backup of the key, or restore from one"))
 	fmt.Println(style.Command("discord [test/fetch/decrypt]", "Show Discord status, test connection, or fetch+decrypt last message"))
+	fmt.Println(style.Command("stego embed/extract/capacity", "Hide encrypted data in PNG images"))
+	fmt.Println(style.Command("analyze crack/freq <data>", "Crack classical ciphers or show letter frequencies"))
+	fmt.Println(style.Command("detect <data>", "Show likely input formats with confidence"))
+	fmt.Println(style.Command("magic [--aes] <data>", "Recursively decode layered encodings"))
+	fmt.Println(style.Command("recipe add/list/remove", "Manage transform pipeline recipes"))
+	fmt.Println(style.Command("profile create/use/list/delete", "Save and switch between settings profiles"))
 	fmt.Println(style.Command("set <setting> <val>", "Set a configuration value"))
 	fmt.Println(style.Command("toggle, t <setting>", "Toggle a setting"))
 	fmt.Println(style.Command("exit, quit, q", "Exit the program"))
 
 	fmt.Println(style.Section("⚙️  Settings:"))
 	fmt.Println(style.Setting("mode", "encrypt/decrypt (shown by lock emoji in prompt)"))
-	fmt.Println(style.Setting("output", "hex/base64/binary (encrypted data format, default: hex)"))
+	fmt.Println(style.Setting("datatype", "text/binary (binary encrypts files, hex or base64 as raw bytes)"))
+	fmt.Println(style.Setting("output", "hex/base64/binary/armor/hexdump/url/html/unicode/qp (encrypted data format, default: hex)"))
+	fmt.Println(style.Setting("input", "auto/armor/hexdump/hex/base64/binary/url/html/unicode/qp/text (decrypt input format, default: auto)"))
+	fmt.Println(style.Setting("cipher", "aes-gcm/chacha20-poly1305 (authenticated cipher, default: aes-gcm)"))
+	fmt.Println(style.Setting("clipboard", "on/off (copy results to the clipboard)"))
 	fmt.Println(style.Setting("discord", "true/false (auto-send encrypted data to Discord DM)"))
 	fmt.Println(style.Setting("discord-id", "channel_id (set Discord DM channel ID)"))
+	fmt.Println(style.Setting("fec", "off/low/medium/high/<2-128> (Reed-Solomon parity bytes per block)"))
+	fmt.Println(style.Setting("qr", "on/off (show encrypted output as a terminal QR code)"))
+	fmt.Println(style.Setting("stego", "<cover text>/off (hide output as zero-width characters in cover text)"))
+	fmt.Println(style.Setting("recipe", "<name>/off (run encrypt/decrypt through a stored recipe)"))
+	fmt.Println(style.Setting("classic", "<cipher>/off (classical cipher for plain mode: "+strings.Join(classic.Names, ", ")+")"))
+	fmt.Println(style.Setting("key", "file:<path>/env:<VAR>/command:<cmd>/default (saved as a reference, never the key)"))
 
 	fmt.Println(style.Section("🔄 How It Works:"))
 	fmt.Printf("  %s\n", style.Info.Sprint("ENCRYPT: text input → AES-GCM → hex/base64/binary output → clipboard + Discord"))
@@ -264,11 +368,26 @@ func showHelp() {
 	fmt.Println(style.Example("encrypt hello world", "encrypt text + send to Discord"))
 	fmt.Println(style.Example("decrypt a1b2c3d4...", "decrypt any format to text"))
 	fmt.Println(style.Example("set output base64", "use base64 encoding"))
+	fmt.Println(style.Example("set cipher chacha20-poly1305", "encrypt with ChaCha20-Poly1305"))
+	fmt.Println(style.Example("set datatype binary", "encrypt files: encrypt ./photo.jpg"))
 	fmt.Println(style.Example("set discord on", "enable Discord sending"))
 	fmt.Println(style.Example("set discord-id 123456789", "set Discord DM channel ID"))
 	fmt.Println(style.Example("discord fetch", "fetch and decrypt last Discord message"))
 	fmt.Println(style.Example("toggle discord", "toggle Discord on/off"))
-	fmt.Println(style.Example("key secretpassword", "set encryption key"))
+	fmt.Println(style.Example("set qr on", "show results as QR codes"))
+	fmt.Println(style.Example("set fec medium", "survive typos and OCR errors in the output"))
+	fmt.Println(style.Example("set stego see you at 5", "hide output inside a normal sentence"))
+	fmt.Println(style.Example("stego embed in.png out.png hi", "hide encrypted text in an image"))
+	fmt.Println(style.Example("set classic vigenere:lemon", "encode with a classical cipher (encryption off)"))
+	fmt.Println(style.Example("analyze crack uryyb jbeyq", "rank likely plaintexts"))
+	fmt.Println(style.Example("magic NjE2MjYz", "peel base64-of-hex and similar layers"))
+	fmt.Println(style.Example("recipe add safe: gzip | aes-gcm | base58", "define a transform pipeline"))
+	fmt.Println(style.Example("set recipe safe", "encrypt/decrypt through the recipe"))
+	fmt.Println(style.Example("profile create ops", "save output, encryption, cipher, key and Discord settings"))
+	fmt.Println(style.Example("profile use ops", "switch back to them in one command"))
+	fmt.Println(style.Example("key", "set encryption key (asks twice, input hidden)"))
+	fmt.Println(style.Example("key --command pass show team/t2b", "take the key from a password manager"))
+	fmt.Println(style.Example("key backup key.pdf", "write a printable key backup"))
 	fmt.Println()
 }
 
@@ -285,29 +404,78 @@ func showSettings() {
 		emoji = "🔓"
 	}
 
-	encType := "AES-GCM"
+	encType := config.Ciphers[cfg.Cipher]
 	if !cfg.UseEncryption {
 		encType = "plain"
 	}
 
 	modeDisplay = fmt.Sprintf("%s %s (%s)", cfg.Mode, emoji, encType)
 	fmt.Println(style.Setting("Mode", modeDisplay))
-	fmt.Println(style.Setting("Output Format", cfg.OutputMode))
+	if cfg.Profile != "" {
+		fmt.Println(style.Setting("Profile", cfg.Profile+sourceTag("profile")))
+	}
+	fmt.Println(style.Setting("Data Type", cfg.DataType+sourceTag("datatype")))
+	fmt.Println(style.Setting("Output Format", cfg.OutputMode+sourceTag("output")))
+	fmt.Println(style.Setting("Input Format", cfg.InputFormat+sourceTag("input")))
 
 	// Key information
 	keyInfo := cfg.GetKeyFingerprint()
 	if cfg.IsDefaultKey() {
-		keyInfo = keyInfo + " " + style.Warning.Sprint("(default - consider changing!)")
+		note := "(default - encryption refused; type 'key' to set one)"
+		if allowDefaultKey {
+			note = "(default - allowed by --allow-default-key)"
+		}
+		keyInfo = keyInfo + " " + style.Warning.Sprint(note)
+	} else if cfg.KeyPending() {
+		keyInfo = style.Gray.Sprint("(not loaded yet; the command runs when the key is needed)")
 	} else {
 		keyInfo = keyInfo + " " + style.Success.Sprint("(custom)")
 	}
-	fmt.Println(style.Setting("Key Fingerprint", keyInfo))
+	if cfg.KeyRef != "" {
+		keyInfo += " " + cfg.KeyRef
+	}
+	fmt.Println(style.Setting("Key Fingerprint", keyInfo+sourceTag("key")))
+
+	qrDisplay := "off"
+	if cfg.ShowQR {
+		qrDisplay = "on"
+	}
+	fmt.Println(style.Setting("QR Output", qrDisplay+sourceTag("qr")))
+
+	clipboardDisplay := "on"
+	if !cfg.Clipboard {
+		clipboardDisplay = "off"
+	}
+	fmt.Println(style.Setting("Clipboard", clipboardDisplay+sourceTag("clipboard")))
+
+	fecDisplay := "off"
+	if cfg.FEC > 0 {
+		fecDisplay = fmt.Sprintf("%d parity bytes per block", cfg.FEC)
+	}
+	fmt.Println(style.Setting("Error Correction", fecDisplay+sourceTag("fec")))
+
+	if cfg.StegoCover != "" {
+		fmt.Println(style.Setting("Stego Cover", fmt.Sprintf("%q", cfg.StegoCover)))
+	}
 
 	if cfg.UseEncryption {
-		fmt.Println(style.Setting("Encryption", "Enabled - AES-256-GCM"))
+		fmt.Println(style.Setting("Encryption", "Enabled - "+config.Ciphers[cfg.Cipher]+sourceTag("encryption")))
 		fmt.Println(style.Setting("Key Derivation", "SHA-256"))
 	} else {
-		fmt.Println(style.Setting("Encryption", "Off"))
+		fmt.Println(style.Setting("Encryption", "Off"+sourceTag("encryption")))
+	}
+	fmt.Println(style.Setting("Cipher", config.Ciphers[cfg.Cipher]+sourceTag("cipher")))
+
+	if cfg.Recipe != "" {
+		fmt.Println(style.Setting("Recipe", fmt.Sprintf("%s (%s)", cfg.Recipe, cfg.Recipes[cfg.Recipe])+sourceTag("recipe")))
+	}
+
+	if cfg.Classic != "" {
+		classicDisplay := cfg.Classic
+		if cfg.UseEncryption {
+			classicDisplay += " " + style.Warning.Sprint("(inactive - encryption on)")
+		}
+		fmt.Println(style.Setting("Classic", classicDisplay+sourceTag("classic")))
 	}
 
 	// Discord integration
@@ -324,70 +492,41 @@ func showSettings() {
 	} else {
 		discordDisplay = discordDisplay + " - " + discordMessage
 	}
-	fmt.Println(style.Setting("Discord", discordDisplay))
+	fmt.Println(style.Setting("Discord", discordDisplay+sourceTag("discord")))
 
 	// Show Discord channel ID if available
 	if dmID := discord.GetDMID(); dmID != "" {
-		fmt.Println(style.Setting("Discord DM ID", dmID))
+		fmt.Println(style.Setting("Discord DM ID", dmID+sourceTag("discord-id")))
 	} else {
 		fmt.Println(style.Setting("Discord DM ID", style.Warning.Sprint("not set")))
 	}
 
+	if path, err := config.FilePath(); err == nil {
+		fmt.Println(style.Setting("Config File", path))
+	}
+	if len(cfg.EnvOverrides) > 0 {
+		fmt.Println(style.Setting("Environment", strings.Join(cfg.EnvOverrides, ", ")))
+	}
+
 	fmt.Println()
 }
 
+// sourceTag labels a setting with where its value came from
+func sourceTag(name string) string {
+	return " " + style.Gray.Sprintf("[%s]", cfg.SourceOf(name))
+}
+
 func handleSet(setting, value string, p *prompt.Prompt) {
-	switch strings.ToLower(setting) {
-	case "mode":
-		if cfg.SetMode(value) {
-			fmt.Printf("%s\n", style.Success.Sprintf("Mode set to: %s", value))
-			p.UpdatePrompt(cfg.Mode) // Update prompt with new mode
-		} else {
-			fmt.Println(style.ErrorMsg(fmt.Errorf("mode must be 'encrypt' or 'decrypt'")))
-		}
-	case "output", "format":
-		if cfg.SetOutputMode(value) {
-			fmt.Printf("%s\n", style.Success.Sprintf("Output mode set to: %s", value))
-		} else {
-			fmt.Println(style.ErrorMsg(fmt.Errorf("output mode must be 'hex', 'base64', or 'binary'")))
-		}
-	case "discord":
-		switch value {
-		case "true", "on", "enable":
-			if cfg.SetDiscordSending(true) {
-				fmt.Printf("%s\n", style.Success.Sprintf("Discord sending enabled"))
-			} else {
-				fmt.Println(style.ErrorMsg(fmt.Errorf("discord not configured (missing DISCORD_USER_TOKEN or DISCORD_DM_ID)")))
-			}
-		case "false", "off", "disable":
-			cfg.SetDiscordSending(false)
-			fmt.Printf("%s\n", style.Success.Sprintf("Discord sending disabled"))
-		default:
-			fmt.Println(style.ErrorMsg(fmt.Errorf("discord must be 'true/on/enable' or 'false/off/disable'")))
-		}
-	case "encryption":
-		switch value {
-		case "true", "on", "enable":
-			cfg.SetEncryption(true)
-			fmt.Printf("%s\n", style.Success.Sprintf("Encryption enabled - using AES-GCM"))
-			p.UpdatePrompt(cfg.Mode)
-		case "false", "off", "disable":
-			cfg.SetEncryption(false)
-			fmt.Printf("%s\n", style.Success.Sprintf("Encryption disabled - using plain encoding"))
-			p.UpdatePrompt(cfg.Mode)
-		default:
-			fmt.Println(style.ErrorMsg(fmt.Errorf("encryption must be 'true/on/enable' or 'false/off/disable'")))
-		}
-	case "discord-id", "dmid":
-		discord := cfg.GetDiscord()
-		if discord.SetDMID(value) {
-			fmt.Printf("%s\n", style.Success.Sprintf("Discord DM ID set to: %s", value))
-		} else {
-			fmt.Println(style.ErrorMsg(fmt.Errorf("invalid Discord DM ID (cannot be empty)")))
-		}
-	default:
-		fmt.Printf("%s\n", style.ErrorMsg(fmt.Errorf("unknown setting: %s", setting)))
+	msg, note, err := applySetting(setting, value, config.SourceShell)
+	if err != nil {
+		fmt.Println(style.ErrorMsg(err))
+		return
 	}
+	fmt.Printf("%s\n", style.Success.Sprint(msg))
+	if note != "" {
+		fmt.Println(style.Info.Sprint(note))
+	}
+	p.UpdatePrompt(cfg.Mode) // Mode and encryption changes show in the prompt
 }
 
 func handleToggle(setting string, p *prompt.Prompt) {
@@ -396,27 +535,42 @@ func handleToggle(setting string, p *prompt.Prompt) {
 		cfg.ToggleMode()
 		fmt.Printf("%s\n", style.Success.Sprintf("Mode toggled to: %s", cfg.Mode))
 		p.UpdatePrompt(cfg.Mode) // Update prompt with new mode
+	case "datatype", "type":
+		cfg.ToggleDataType()
+		cfg.MarkSet("datatype", config.SourceShell)
+		fmt.Printf("%s\n", style.Success.Sprintf("Data type toggled to: %s", cfg.DataType))
 	case "output", "format":
 		cfg.ToggleOutputMode()
+		cfg.MarkSet("output", config.SourceShell)
 		fmt.Printf("%s\n", style.Success.Sprintf("Output mode toggled to: %s", cfg.OutputMode))
 	case "discord":
 		if cfg.ToggleDiscord() {
+			cfg.MarkSet("discord", config.SourceShell)
 			status := "disabled"
 			if cfg.SendToDiscord {
 				status = "enabled"
 			}
 			fmt.Printf("%s\n", style.Success.Sprintf("Discord sending toggled to: %s", status))
 		} else {
-			fmt.Println(style.ErrorMsg(fmt.Errorf("discord not configured (missing DISCORD_USER_TOKEN or DISCORD_DM_ID)")))
+			fmt.Println(style.ErrorMsg(discord.ErrNotConfigured))
 		}
 	case "encryption":
 		cfg.ToggleEncryption()
+		cfg.MarkSet("encryption", config.SourceShell)
 		status := "disabled (plain encoding)"
 		if cfg.UseEncryption {
-			status = "enabled (AES-GCM)"
+			status = fmt.Sprintf("enabled (%s)", config.Ciphers[cfg.Cipher])
 		}
 		fmt.Printf("%s\n", style.Success.Sprintf("Encryption toggled to: %s", status))
 		p.UpdatePrompt(cfg.Mode)
+	case "qr":
+		cfg.ShowQR = !cfg.ShowQR
+		cfg.MarkSet("qr", config.SourceShell)
+		status := "disabled"
+		if cfg.ShowQR {
+			status = "enabled"
+		}
+		fmt.Printf("%s\n", style.Success.Sprintf("QR output toggled to: %s", status))
 	default:
 		fmt.Printf("%s\n", style.ErrorMsg(fmt.Errorf("cannot toggle setting: %s", setting)))
 	}
@@ -426,7 +580,7 @@ func handleModeCommand(mode string, p *prompt.Prompt) {
 	switch strings.ToLower(mode) {
 	case "encrypt", "e":
 		cfg.SetMode("encrypt")
-		encType := "AES-GCM"
+		encType := config.Ciphers[cfg.Cipher]
 		if !cfg.UseEncryption {
 			encType = "plain"
 		}
@@ -434,7 +588,7 @@ func handleModeCommand(mode string, p *prompt.Prompt) {
 		p.UpdatePrompt("encrypt")
 	case "decrypt", "d":
 		cfg.SetMode("decrypt")
-		encType := "AES-GCM"
+		encType := config.Ciphers[cfg.Cipher]
 		if !cfg.UseEncryption {
 			encType = "plain"
 		}
@@ -442,7 +596,7 @@ func handleModeCommand(mode string, p *prompt.Prompt) {
 		p.UpdatePrompt("decrypt")
 	case "toggle", "t":
 		cfg.ToggleMode()
-		encType := "AES-GCM"
+		encType := config.Ciphers[cfg.Cipher]
 		if !cfg.UseEncryption {
 			encType = "plain"
 		}
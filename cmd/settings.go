@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"doc0x1/text2babe/internal/config"
+	"doc0x1/text2babe/internal/crypto"
+	"doc0x1/text2babe/internal/style"
+)
+
+// Global flags; each one maps onto a shell setting
+var (
+	keyFlag       string
+	keyFileFlag   string
+	keyEnvFlag    string
+	keyCmdFlag    string
+	outputFlag    string
+	encryptFlag   bool
+	plainFlag     bool
+	cipherFlag    string
+	noClipboard   bool
+	noDiscordFlag bool
+	discordIDFlag string
+)
+
+// applySetting changes one setting with the same validation for the shell's
+// `set` command and the command-line flags. It returns a confirmation and an
+// optional note to show after it.
+func applySetting(setting, value string, src config.Source) (msg, note string, err error) {
+	name := config.SettingName(setting)
+	if name == "input" && !cfg.SetInputFormat(value) {
+		return "", "", fmt.Errorf("input format must be one of: %s", strings.Join(crypto.InputFormats(), ", "))
+	}
+	if err := cfg.Set(name, value, src); err != nil {
+		return "", "", err
+	}
+
+	switch name {
+	case "mode":
+		return fmt.Sprintf("Mode set to: %s", value), "", nil
+	case "datatype":
+		if value == "binary" {
+			note = "encrypt now takes a file path, hex or base64 input"
+		}
+		return fmt.Sprintf("Data type set to: %s", value), note, nil
+	case "output":
+		return fmt.Sprintf("Output mode set to: %s", value), "", nil
+	case "input":
+		return fmt.Sprintf("Input format set to: %s", value), "", nil
+	case "recipe":
+		if cfg.Recipe == "" {
+			return "Recipe disabled", "", nil
+		}
+		return fmt.Sprintf("Using recipe: %s (%s)", value, cfg.Recipes[value]), "", nil
+	case "discord":
+		if cfg.SendToDiscord {
+			return "Discord sending enabled", "", nil
+		}
+		return "Discord sending disabled", "", nil
+	case "encryption":
+		if cfg.UseEncryption {
+			return fmt.Sprintf("Encryption enabled - using %s", config.Ciphers[cfg.Cipher]), "", nil
+		}
+		return "Encryption disabled - using plain encoding", "", nil
+	case "cipher":
+		if !cfg.UseEncryption {
+			note = "The cipher only applies with encryption on ('set encryption on')"
+		}
+		return fmt.Sprintf("Cipher set to: %s", config.Ciphers[cfg.Cipher]), note, nil
+	case "clipboard":
+		if cfg.Clipboard {
+			return "Clipboard copying enabled", "", nil
+		}
+		return "Clipboard copying disabled", "", nil
+	case "qr":
+		if cfg.ShowQR {
+			return "QR output enabled", "", nil
+		}
+		return "QR output disabled", "", nil
+	case "stego":
+		if cfg.StegoCover == "" {
+			return "Steganography disabled", "", nil
+		}
+		return fmt.Sprintf("Hiding output in cover text: %q", value), "", nil
+	case "classic":
+		if cfg.Classic == "" {
+			return "Classical cipher disabled", "", nil
+		}
+		if cfg.UseEncryption {
+			note = "Classical ciphers only apply with encryption off ('set encryption off')"
+		}
+		return fmt.Sprintf("Classical cipher set to: %s", value), note, nil
+	case "fec":
+		if cfg.FEC == 0 {
+			return "Error correction disabled", "", nil
+		}
+		return fmt.Sprintf("Error correction set to %d parity bytes per block (repairs up to %d damaged bytes each)", cfg.FEC, cfg.FEC/2), "", nil
+	case "discord-id":
+		return fmt.Sprintf("Discord DM ID set to: %s", value), "", nil
+	case "profile":
+		if cfg.Profile == "" {
+			return "Profile cleared (its settings stay in effect)", "", nil
+		}
+		return fmt.Sprintf("Using profile %s", cfg.Profile), "", nil
+	case "key":
+		// Run a key command now, so a broken one is reported where it is set
+		if err := cfg.LoadKey(); err != nil {
+			return "", "", err
+		}
+		if cfg.IsDefaultKey() {
+			return "Using the default key", "", nil
+		}
+		return fmt.Sprintf("Key loaded from %s (fingerprint: %s)", cfg.KeyRef, cfg.GetKeyFingerprint()), "", nil
+	}
+	return fmt.Sprintf("%s set to: %s", name, value), "", nil
+}
+
+// applyFlags binds the global flags that were given on the command line into cfg
+func applyFlags(cmd *cobra.Command, args []string) error {
+	if jsonOutput {
+		setupJSON()
+		cmd.SilenceUsage = true // errors are reported as JSON
+	}
+	for _, warning := range cfg.Warnings {
+		fmt.Fprintln(statusOut, style.WarningMsg(warning))
+	}
+	flags := cmd.Flags()
+	settings := []struct {
+		flag, setting, value string
+	}{
+		{"output", "output", outputFlag},
+		{"cipher", "cipher", cipherFlag},
+		{"encrypt", "encryption", "on"},
+		{"plain", "encryption", "off"},
+		{"no-clipboard", "clipboard", "off"},
+		{"no-discord", "discord", "off"},
+		{"discord-id", "discord-id", discordIDFlag},
+	}
+	for _, s := range settings {
+		if !flags.Changed(s.flag) {
+			continue
+		}
+		// Boolean flags can be switched back off with --flag=false
+		if b, err := flags.GetBool(s.flag); err == nil && !b {
+			continue
+		}
+		if _, _, err := applySetting(s.setting, s.value, config.SourceFlag); err != nil {
+			return fmt.Errorf("--%s: %w", s.flag, err)
+		}
+	}
+
+	if flags.Changed("key") {
+		if keyFlag == "" {
+			return fmt.Errorf("--key: key cannot be empty")
+		}
+		cfg.SetKey(keyFlag)
+		cfg.MarkSet("key", config.SourceFlag)
+	}
+	keySources := []struct {
+		flag, kind, value string
+	}{
+		{"key-file", "file", keyFileFlag},
+		{"key-env", "env", keyEnvFlag},
+		{"key-command", "command", keyCmdFlag},
+	}
+	for _, k := range keySources {
+		if !flags.Changed(k.flag) {
+			continue
+		}
+		if err := cfg.Set("key", k.kind+":"+k.value, config.SourceFlag); err != nil {
+			return fmt.Errorf("--%s: %w", k.flag, err)
+		}
+	}
+
+	// Anything that fails from here on is not a usage mistake
+	cmd.SilenceUsage = true
+	commandStarted = true
+	return nil
+}
+
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.StringVar(&keyFlag, "key", "", "Encryption password (visible in process lists; prefer --key-file)")
+	flags.StringVar(&keyFileFlag, "key-file", "", "Read the key from a file (t2b-key: string or password)")
+	flags.StringVar(&keyEnvFlag, "key-env", "", "Read the key from an environment variable")
+	flags.StringVar(&keyCmdFlag, "key-command", "", "Read the key from a command's output, e.g. \"pass show team/t2b\"")
+	flags.StringVar(&outputFlag, "output", "", "Output format: hex, base64, binary, armor, hexdump, url, html, unicode or qp")
+	flags.BoolVar(&encryptFlag, "encrypt", false, "Turn encryption on")
+	flags.BoolVar(&plainFlag, "plain", false, "Turn encryption off (plain encoding)")
+	flags.StringVar(&cipherFlag, "cipher", "", "Authenticated cipher: aes-gcm or chacha20-poly1305")
+	flags.BoolVar(&noClipboard, "no-clipboard", false, "Don't copy results to the clipboard")
+	flags.BoolVar(&noDiscordFlag, "no-discord", false, "Don't send results to Discord")
+	flags.StringVar(&discordIDFlag, "discord-id", "", "Discord DM channel ID to send to")
+	flags.BoolVar(&allowDefaultKey, "allow-default-key", false, "Allow encrypting with the built-in default key")
+	flags.BoolVar(&jsonOutput, "json", false, "Print results and errors as JSON")
+	rootCmd.MarkFlagsMutuallyExclusive("encrypt", "plain")
+	rootCmd.MarkFlagsMutuallyExclusive("key", "key-file", "key-env", "key-command")
+	rootCmd.PersistentPreRunE = applyFlags
+}
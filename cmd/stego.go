@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"doc0x1/text2babe/internal/crypto"
+	"doc0x1/text2babe/internal/stego"
+	"doc0x1/text2babe/internal/style"
+)
+
+var (
+	stegoIn      string
+	stegoOut     string
+	stegoDiscord bool
+)
+
+var stegoCmd = &cobra.Command{
+	Use:   "stego",
+	Short: "Hide encrypted data inside PNG images",
+	Long:  "Embed AES-GCM encrypted payloads in the least significant bits of lossless PNG images, or extract them again.",
+}
+
+var stegoEmbedCmd = &cobra.Command{
+	Use:   "embed [data]",
+	Short: "Hide encrypted data in a PNG image",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		used, capacity, err := embedImage(stegoIn, stegoOut, strings.Join(args, " "), stegoDiscord)
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			out := struct {
+				File     string       `json:"file"`
+				Bytes    int          `json:"bytes"`
+				Capacity int          `json:"capacity"`
+				Discord  *discordJSON `json:"discord,omitempty"`
+			}{File: stegoOut, Bytes: used, Capacity: capacity}
+			if stegoDiscord {
+				out.Discord = &discordJSON{Sent: true}
+			}
+			return printJSON(out)
+		}
+		return nil
+	},
+}
+
+var stegoExtractCmd = &cobra.Command{
+	Use:   "extract",
+	Short: "Extract and decrypt data hidden in a PNG image",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		result, err := extractImage(stegoIn)
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(map[string]string{"result": result})
+		}
+		fmt.Println(style.Result("Extracted", result))
+		return nil
+	},
+}
+
+var stegoCapacityCmd = &cobra.Command{
+	Use:   "capacity",
+	Short: "Show how much data a PNG image can hold",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showImageCapacity(stegoIn)
+	},
+}
+
+func init() {
+	stegoCmd.PersistentFlags().StringVar(&stegoIn, "in", "", "Input PNG image")
+	stegoCmd.MarkPersistentFlagRequired("in")
+	stegoEmbedCmd.Flags().StringVar(&stegoOut, "out", "", "Output PNG image")
+	stegoEmbedCmd.MarkFlagRequired("out")
+	stegoEmbedCmd.Flags().BoolVar(&stegoDiscord, "discord", false, "Send the image to the Discord DM as an attachment")
+
+	stegoCmd.AddCommand(stegoEmbedCmd)
+	stegoCmd.AddCommand(stegoExtractCmd)
+	stegoCmd.AddCommand(stegoCapacityCmd)
+}
+
+func handleStegoCommand(parts []string) {
+	if len(parts) < 3 {
+		fmt.Println("Usage: stego embed <in.png> <out.png> <data> | stego extract <in.png> | stego capacity <in.png>")
+		return
+	}
+
+	var err error
+	switch strings.ToLower(parts[1]) {
+	case "embed":
+		if len(parts) < 5 {
+			fmt.Println("Usage: stego embed <in.png> <out.png> <data>")
+			return
+		}
+		_, _, err = embedImage(parts[2], parts[3], strings.Join(parts[4:], " "), false)
+	case "extract":
+		var result string
+		if result, err = extractImage(parts[2]); err == nil {
+			fmt.Println(style.Result("Extracted", result))
+		}
+	case "capacity":
+		err = showImageCapacity(parts[2])
+	default:
+		err = fmt.Errorf("unknown stego command: %s", parts[1])
+	}
+	if err != nil {
+		fmt.Println(style.ErrorMsg(err))
+	}
+}
+
+// embedImage always seals the payload with AES-GCM so that extracting
+// without the key only yields noise, regardless of the encryption setting.
+// It returns the payload size and the image capacity in bytes.
+func embedImage(in, out, data string, send bool) (used, capacity int, err error) {
+	if err := checkKey(true); err != nil {
+		return 0, 0, err
+	}
+	img, err := loadPNG(in)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := cfg.LoadKey(); err != nil {
+		return 0, 0, err
+	}
+	payload, err := crypto.Seal(cfg.Key, []byte(data))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	capacity = stego.ImageCapacity(img)
+	result, err := stego.EmbedImage(img, payload)
+	if err != nil {
+		return 0, capacity, err
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return 0, capacity, err
+	}
+	if err := png.Encode(f, result); err != nil {
+		f.Close()
+		return 0, capacity, fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	if err := f.Close(); err != nil {
+		return 0, capacity, err
+	}
+
+	fmt.Println(style.Success.Sprintf("✓ Hidden %d bytes in %s", len(payload), out))
+	fmt.Println(style.Setting("Capacity", fmt.Sprintf("%d/%d bytes used (%.1f%%)", len(payload), capacity, float64(len(payload))*100/float64(capacity))))
+	if cfg.IsDefaultKey() {
+		fmt.Println(style.WarningMsg("Payload sealed with the default key - set a key with 'key' in the shell (hidden input) or 'text2babe key --file/--env/--command'"))
+	}
+
+	if send {
+		discord := cfg.GetDiscord()
+		f, err := os.Open(out)
+		if err != nil {
+			return len(payload), capacity, err
+		}
+		defer f.Close()
+		if err := discord.SendFile(filepath.Base(out), f); err != nil {
+			return len(payload), capacity, discordError(fmt.Errorf("failed to send to Discord: %w", err))
+		}
+		fmt.Println(style.Success.Sprint("📨 Sent to Discord!"))
+	}
+	return len(payload), capacity, nil
+}
+
+func extractImage(in string) (string, error) {
+	img, err := loadPNG(in)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := stego.ExtractImage(img)
+	if err != nil {
+		return "", err
+	}
+
+	if err := cfg.LoadKey(); err != nil {
+		return "", err
+	}
+	plaintext, err := crypto.Open(cfg.Key, payload)
+	if err != nil {
+		return "", fmt.Errorf("no readable payload (wrong key or no hidden data): %w", crypto.ErrAuthenticationFailed)
+	}
+	return string(plaintext), nil
+}
+
+func showImageCapacity(in string) error {
+	img, err := loadPNG(in)
+	if err != nil {
+		return err
+	}
+	b := img.Bounds()
+	if jsonOutput {
+		return printJSON(struct {
+			Image    string `json:"image"`
+			Width    int    `json:"width"`
+			Height   int    `json:"height"`
+			Capacity int    `json:"capacity"`
+		}{in, b.Dx(), b.Dy(), stego.ImageCapacity(img)})
+	}
+	fmt.Println(style.Setting("Image", fmt.Sprintf("%s (%dx%d)", in, b.Dx(), b.Dy())))
+	fmt.Println(style.Setting("Capacity", fmt.Sprintf("%d bytes of ciphertext", stego.ImageCapacity(img))))
+	return nil
+}
+
+func loadPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a PNG image (lossy formats destroy hidden data): %w", path, err)
+	}
+	return img, nil
+}
@@ -0,0 +1,289 @@
+package analyze
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"doc0x1/text2babe/internal/classic"
+)
+
+// Candidate is one possible decryption
+type Candidate struct {
+	Method    string
+	Key       string
+	Plaintext []byte
+	Score     float64 // Lower is more English-like
+}
+
+const (
+	maxVigenereKey = 20
+	minColumn      = 6 // Minimum samples per key column
+)
+
+// Crack runs every cracker and returns the best candidates first. text is
+// the input as typed; raw is the decoded byte form used for XOR attacks.
+func Crack(text string, raw []byte, limit int) []Candidate {
+	var all []Candidate
+	all = append(all, CrackCaesar([]byte(text))...)
+	all = append(all, CrackAtbash([]byte(text))...)
+	all = append(all, CrackVigenere([]byte(text))...)
+	all = append(all, CrackSingleXOR(raw)...)
+	all = append(all, CrackRepeatingXOR(raw)...)
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Score < all[j].Score })
+
+	// Drop duplicate plaintexts (e.g. a Vigenère key of one letter is a Caesar shift)
+	seen := make(map[string]bool)
+	var ranked []Candidate
+	for _, c := range all {
+		if seen[string(c.Plaintext)] {
+			continue
+		}
+		seen[string(c.Plaintext)] = true
+		ranked = append(ranked, c)
+		if limit > 0 && len(ranked) == limit {
+			break
+		}
+	}
+	return ranked
+}
+
+func decode(c classic.Cipher, data []byte) []byte {
+	out, err := c.Decode(data)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// CrackCaesar tries all 25 shifts
+func CrackCaesar(text []byte) []Candidate {
+	if _, letters := letterCounts(text); letters == 0 {
+		return nil
+	}
+	var candidates []Candidate
+	for shift := 1; shift < 26; shift++ {
+		plain := decode(classic.Caesar{Shift: shift}, text)
+		c := Candidate{Method: "caesar", Key: fmt.Sprint(shift), Plaintext: plain, Score: Score(plain)}
+		if shift == 13 {
+			c.Method, c.Key = "rot13", ""
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates
+}
+
+// CrackAtbash scores the Atbash mirror of text
+func CrackAtbash(text []byte) []Candidate {
+	if _, letters := letterCounts(text); letters == 0 {
+		return nil
+	}
+	plain := decode(classic.Atbash{}, text)
+	return []Candidate{{Method: "atbash", Plaintext: plain, Score: Score(plain)}}
+}
+
+// CrackVigenere guesses key lengths with the index of coincidence and
+// Kasiski examination, then solves each key column as a Caesar shift
+func CrackVigenere(text []byte) []Candidate {
+	var letters []byte
+	for _, b := range text {
+		if (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') {
+			letters = append(letters, b|0x20)
+		}
+	}
+	if len(letters) < 8 {
+		return nil
+	}
+
+	var candidates []Candidate
+	for _, length := range vigenereKeyLengths(letters) {
+		key := make([]byte, length)
+		for col := 0; col < length; col++ {
+			var column []byte
+			for i := col; i < len(letters); i += length {
+				column = append(column, letters[i])
+			}
+			best, bestChi := 0, 0.0
+			for shift := 0; shift < 26; shift++ {
+				chi := ChiSquared(decode(classic.Caesar{Shift: shift}, column))
+				if shift == 0 || chi < bestChi {
+					best, bestChi = shift, chi
+				}
+			}
+			key[col] = byte('a' + best)
+		}
+		plain := decode(classic.Vigenere{Key: string(key)}, text)
+		score := Score(plain) + keyPenalty(length, len(letters))
+		candidates = append(candidates, Candidate{Method: "vigenere", Key: string(key), Plaintext: plain, Score: score})
+	}
+	return candidates
+}
+
+// vigenereKeyLengths returns the most likely key lengths, best first
+func vigenereKeyLengths(letters []byte) []int {
+	type guess struct {
+		length int
+		ic     float64
+	}
+	var guesses []guess
+	// Require a few letters per key column so short texts don't overfit
+	for length := 1; length <= maxVigenereKey && length <= len(letters)/minColumn; length++ {
+		total := 0.0
+		for col := 0; col < length; col++ {
+			var column []byte
+			for i := col; i < len(letters); i += length {
+				column = append(column, letters[i])
+			}
+			total += IndexOfCoincidence(column)
+		}
+		guesses = append(guesses, guess{length, total / float64(length)})
+	}
+	sort.SliceStable(guesses, func(i, j int) bool { return guesses[i].ic > guesses[j].ic })
+
+	var lengths []int
+	for i := 0; i < len(guesses) && i < 3; i++ {
+		lengths = append(lengths, guesses[i].length)
+	}
+	for _, n := range kasiskiLengths(letters, 2) {
+		if n <= len(letters)/minColumn {
+			lengths = append(lengths, n)
+		}
+	}
+	return withDivisors(lengths)
+}
+
+// keyPenalty grows with key length relative to the text. Every key column
+// is fitted independently, so long keys on short texts always look English.
+func keyPenalty(keyLen, textLen int) float64 {
+	return 2 * float64(keyLen*keyLen) / float64(textLen)
+}
+
+// withDivisors adds the divisors of every guess. Multiples of the real key
+// length score well too, but solving them spreads the text over more columns.
+func withDivisors(sizes []int) []int {
+	seen := make(map[int]bool)
+	var out []int
+	for _, n := range sizes {
+		for d := 1; d <= n; d++ {
+			if n%d == 0 && !seen[d] {
+				seen[d] = true
+				out = append(out, d)
+			}
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+// kasiskiLengths finds key lengths that divide the distances between repeated trigrams
+func kasiskiLengths(letters []byte, limit int) []int {
+	positions := make(map[string][]int)
+	for i := 0; i+3 <= len(letters); i++ {
+		positions[string(letters[i:i+3])] = append(positions[string(letters[i:i+3])], i)
+	}
+
+	var factors [maxVigenereKey + 1]int
+	for _, pos := range positions {
+		for i := 1; i < len(pos); i++ {
+			dist := pos[i] - pos[i-1]
+			for f := 2; f <= maxVigenereKey; f++ {
+				if dist%f == 0 {
+					factors[f]++
+				}
+			}
+		}
+	}
+
+	var lengths []int
+	for len(lengths) < limit {
+		best := 0
+		for f := 2; f <= maxVigenereKey; f++ {
+			if factors[f] > factors[best] {
+				best = f
+			}
+		}
+		if factors[best] == 0 {
+			break
+		}
+		lengths = append(lengths, best)
+		factors[best] = 0
+	}
+	return lengths
+}
+
+// CrackSingleXOR tries every single-byte key and keeps the best few
+func CrackSingleXOR(data []byte) []Candidate {
+	if len(data) == 0 {
+		return nil
+	}
+	var candidates []Candidate
+	for k := 1; k < 256; k++ {
+		plain := decode(classic.XOR{Key: []byte{byte(k)}}, data)
+		candidates = append(candidates, Candidate{Method: "xor", Key: fmt.Sprintf("0x%02x", k), Plaintext: plain, Score: Score(plain)})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score < candidates[j].Score })
+	return candidates[:min(3, len(candidates))]
+}
+
+// CrackRepeatingXOR sizes the key with normalized Hamming distance between
+// blocks, then solves each key byte as a single-byte XOR
+func CrackRepeatingXOR(data []byte) []Candidate {
+	var candidates []Candidate
+	for _, size := range withDivisors(xorKeySizes(data, 4)) {
+		if size < 2 {
+			continue // Covered by CrackSingleXOR
+		}
+		key := make([]byte, size)
+		for col := 0; col < size; col++ {
+			var column []byte
+			for i := col; i < len(data); i += size {
+				column = append(column, data[i])
+			}
+			best, bestScore := 0, 0.0
+			for k := 0; k < 256; k++ {
+				score := Score(decode(classic.XOR{Key: []byte{byte(k)}}, column))
+				if k == 0 || score < bestScore {
+					best, bestScore = k, score
+				}
+			}
+			key[col] = byte(best)
+		}
+		plain := decode(classic.XOR{Key: key}, data)
+		score := Score(plain) + keyPenalty(size, len(data))
+		candidates = append(candidates, Candidate{Method: "xor-repeating", Key: fmt.Sprintf("%q", key), Plaintext: plain, Score: score})
+	}
+	return candidates
+}
+
+func xorKeySizes(data []byte, limit int) []int {
+	type guess struct {
+		size     int
+		distance float64
+	}
+	var guesses []guess
+	for size := 2; size <= 40 && size*minColumn <= len(data); size++ {
+		total, pairs := 0.0, 0
+		for i := 0; (i+2)*size <= len(data) && pairs < 16; i++ {
+			a, b := data[i*size:(i+1)*size], data[(i+1)*size:(i+2)*size]
+			total += float64(hamming(a, b)) / float64(size)
+			pairs++
+		}
+		guesses = append(guesses, guess{size, total / float64(pairs)})
+	}
+	sort.SliceStable(guesses, func(i, j int) bool { return guesses[i].distance < guesses[j].distance })
+
+	var sizes []int
+	for i := 0; i < len(guesses) && i < limit; i++ {
+		sizes = append(sizes, guesses[i].size)
+	}
+	return sizes
+}
+
+func hamming(a, b []byte) int {
+	d := 0
+	for i := range a {
+		d += bits.OnesCount8(a[i] ^ b[i])
+	}
+	return d
+}
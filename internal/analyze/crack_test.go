@@ -0,0 +1,100 @@
+package analyze
+
+import (
+	"math"
+	"testing"
+
+	"doc0x1/text2babe/internal/classic"
+)
+
+const sample = "It was the best of times, it was the worst of times, it was the age of wisdom, " +
+	"it was the age of foolishness, it was the epoch of belief, it was the epoch of incredulity, " +
+	"it was the season of Light, it was the season of Darkness."
+
+// Each cracker recovers the plaintext as the best candidate
+func TestCrackRecoversPlaintext(t *testing.T) {
+	tests := []struct {
+		spec   string
+		method string
+		key    string
+	}{
+		{"caesar:3", "caesar", "3"},
+		{"caesar:22", "caesar", "22"},
+		{"rot13", "rot13", ""},
+		{"atbash", "atbash", ""},
+		{"vigenere:lemon", "vigenere", "lemon"},
+		{"vigenere:dickens", "vigenere", "dickens"},
+		{"xor:Z", "xor", "0x5a"},
+		{"xor:key", "xor-repeating", `"key"`},
+		{"xor:secret", "xor-repeating", `"secret"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			c, err := classic.Parse(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			encrypted, err := c.Encode([]byte(sample))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var text string
+			if c.Textual() {
+				text = string(encrypted)
+			}
+			candidates := Crack(text, encrypted, 5)
+			if len(candidates) == 0 {
+				t.Fatal("no candidates")
+			}
+			best := candidates[0]
+			if string(best.Plaintext) != sample {
+				t.Fatalf("best candidate %s %s gave %q", best.Method, best.Key, best.Plaintext)
+			}
+			if best.Method != tt.method || best.Key != tt.key {
+				t.Errorf("found by %s key %s, want %s key %s", best.Method, best.Key, tt.method, tt.key)
+			}
+		})
+	}
+}
+
+func TestCrackLimit(t *testing.T) {
+	encrypted := []byte("Wkh txlfn eurzq ira mxpsv ryhu wkh odcb grj")
+	if got := Crack(string(encrypted), encrypted, 3); len(got) != 3 {
+		t.Errorf("got %d candidates, want 3", len(got))
+	}
+	if got := Crack("", nil, 3); len(got) != 0 {
+		t.Errorf("got %d candidates for empty input", len(got))
+	}
+}
+
+func TestIndexOfCoincidence(t *testing.T) {
+	if ic := IndexOfCoincidence([]byte(sample)); math.Abs(ic-0.066) > 0.015 {
+		t.Errorf("English text has IC %.4f, want about 0.066", ic)
+	}
+	if ic := IndexOfCoincidence([]byte("abcdefghijklmnopqrstuvwxyz")); ic != 0 {
+		t.Errorf("distinct letters have IC %.4f, want 0", ic)
+	}
+}
+
+func TestScorePrefersEnglish(t *testing.T) {
+	english := []byte("the quick brown fox jumps over the lazy dog")
+	shifted := decode(classic.Caesar{Shift: 7}, english)
+	if Score(english) >= Score(shifted) {
+		t.Errorf("English scored %.2f, shifted text %.2f", Score(english), Score(shifted))
+	}
+}
+
+func TestFrequencies(t *testing.T) {
+	freqs := Frequencies([]byte("aab!"))
+	if len(freqs) == 0 {
+		t.Fatal("no frequencies")
+	}
+	total := 0.0
+	for _, f := range freqs {
+		total += f.Percent
+	}
+	if math.Abs(total-100) > 0.01 {
+		t.Errorf("percentages add up to %.2f", total)
+	}
+}
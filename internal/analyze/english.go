@@ -0,0 +1,166 @@
+package analyze
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// English letter frequencies (A-Z) as fractions
+var englishFreq = [26]float64{
+	0.08167, 0.01492, 0.02782, 0.04253, 0.12702, 0.02228, 0.02015,
+	0.06094, 0.06966, 0.00153, 0.00772, 0.04025, 0.02406, 0.06749,
+	0.07507, 0.01929, 0.00095, 0.05987, 0.06327, 0.09056, 0.02758,
+	0.00978, 0.02360, 0.00150, 0.01974, 0.00074,
+}
+
+// Most common English bigrams; a high share of these is hard to fake by
+// tuning letter frequencies alone (as over-long Vigenère keys do)
+var commonBigrams = map[string]bool{
+	"th": true, "he": true, "in": true, "er": true, "an": true, "re": true,
+	"on": true, "at": true, "en": true, "nd": true, "ti": true, "es": true,
+	"or": true, "te": true, "of": true, "ed": true, "is": true, "it": true,
+	"al": true, "ar": true, "st": true, "to": true, "nt": true, "ng": true,
+	"se": true, "ha": true, "as": true, "ou": true, "io": true, "le": true,
+	"ve": true, "co": true, "me": true, "de": true, "hi": true, "ri": true,
+	"ro": true, "ic": true, "ne": true, "ea": true, "ra": true, "ce": true,
+}
+
+// Index of coincidence of English text
+const englishIC = 0.0667
+
+func letterCounts(data []byte) (counts [26]int, total int) {
+	for _, b := range data {
+		switch {
+		case b >= 'a' && b <= 'z':
+			counts[b-'a']++
+			total++
+		case b >= 'A' && b <= 'Z':
+			counts[b-'A']++
+			total++
+		}
+	}
+	return counts, total
+}
+
+// ChiSquared compares the letter distribution of data against English
+func ChiSquared(data []byte) float64 {
+	counts, total := letterCounts(data)
+	if total == 0 {
+		return 1e9
+	}
+	chi := 0.0
+	for i, observed := range counts {
+		expected := englishFreq[i] * float64(total)
+		diff := float64(observed) - expected
+		chi += diff * diff / expected
+	}
+	return chi
+}
+
+// IndexOfCoincidence returns the probability that two random letters of data match
+func IndexOfCoincidence(data []byte) float64 {
+	counts, total := letterCounts(data)
+	if total < 2 {
+		return 0
+	}
+	sum := 0
+	for _, c := range counts {
+		sum += c * (c - 1)
+	}
+	return float64(sum) / float64(total*(total-1))
+}
+
+// Score rates how much data looks like English text; lower is better.
+// Letter likelihood and common bigrams are combined with penalties for
+// unprintable bytes and for text that is mostly symbols, so binary garbage
+// ranks last.
+func Score(data []byte) float64 {
+	if len(data) == 0 {
+		return 1e9
+	}
+	_, letters := letterCounts(data)
+	unprintable, spaces := 0, 0
+	for _, b := range data {
+		switch {
+		case b == ' ':
+			spaces++
+		case b == '\n' || b == '\r' || b == '\t':
+		case b < 0x20 || b > 0x7e:
+			unprintable++
+		}
+	}
+
+	n := float64(len(data))
+	score := letterEntropy(data)
+	score -= 4 * bigramShare(data)
+	score += 20 * float64(unprintable) / n
+	score += 5 * (1 - float64(letters+spaces)/n)
+	return score
+}
+
+// letterEntropy is the mean negative log-probability of the letters in data
+// under English frequencies. Unlike chi-squared it stays stable on short texts.
+func letterEntropy(data []byte) float64 {
+	counts, total := letterCounts(data)
+	if total == 0 {
+		return 10
+	}
+	sum := 0.0
+	for i, c := range counts {
+		sum -= float64(c) * math.Log(englishFreq[i])
+	}
+	return sum / float64(total)
+}
+
+// bigramShare returns the fraction of letter pairs that are common English bigrams
+func bigramShare(data []byte) float64 {
+	pairs, common := 0, 0
+	for i := 0; i+1 < len(data); i++ {
+		a, b := data[i]|0x20, data[i+1]|0x20
+		if a < 'a' || a > 'z' || b < 'a' || b > 'z' {
+			continue
+		}
+		pairs++
+		if commonBigrams[string([]byte{a, b})] {
+			common++
+		}
+	}
+	if pairs == 0 {
+		return 0
+	}
+	return float64(common) / float64(pairs)
+}
+
+// Frequency is one row of a letter frequency table
+type Frequency struct {
+	Letter   byte
+	Count    int
+	Percent  float64
+	Expected float64
+}
+
+// Frequencies returns letter counts of data sorted from most to least common
+func Frequencies(data []byte) []Frequency {
+	counts, total := letterCounts(data)
+	rows := make([]Frequency, 0, 26)
+	for i, c := range counts {
+		if c == 0 {
+			continue
+		}
+		rows = append(rows, Frequency{
+			Letter:   byte('A' + i),
+			Count:    c,
+			Percent:  float64(c) * 100 / float64(total),
+			Expected: englishFreq[i] * 100,
+		})
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Count > rows[j].Count })
+	return rows
+}
+
+// FrequencyBar renders a percentage as a simple text bar
+func FrequencyBar(percent float64) string {
+	return strings.Repeat("█", int(percent+0.5)) + fmt.Sprintf(" %.1f%%", percent)
+}
@@ -0,0 +1,225 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"math"
+)
+
+// Bell 202 style AFSK: 1200 baud, 1200 Hz mark (1) and 2200 Hz space (0),
+// with continuous phase. Bytes are sent UART style: a start bit (space),
+// eight data bits LSB first and a stop bit (mark).
+//
+// Frame: mark tone preamble, sync word, 4-byte big-endian length, payload,
+// CRC-32 (IEEE) of the payload, then a short mark tail.
+
+const (
+	SampleRate = 48000
+	Baud       = 1200
+	markFreq   = 1200.0
+	spaceFreq  = 2200.0
+	amplitude  = 0.5
+
+	preambleBits = Baud / 4 // 250 ms of idle mark tone
+	tailBits     = Baud / 20
+	maxPayload   = 1 << 20
+)
+
+var syncWord = []byte{0x7e, 'T', '2', 'B'}
+
+// Modulate returns 16-bit samples at SampleRate carrying payload
+func Modulate(payload []byte) []int16 {
+	frame := append([]byte(nil), syncWord...)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(payload)))
+	frame = append(frame, payload...)
+	frame = binary.BigEndian.AppendUint32(frame, crc32.ChecksumIEEE(payload))
+
+	var bits []bool
+	for i := 0; i < preambleBits; i++ {
+		bits = append(bits, true)
+	}
+	for _, b := range frame {
+		bits = append(bits, false)
+		for i := 0; i < 8; i++ {
+			bits = append(bits, b>>i&1 == 1)
+		}
+		bits = append(bits, true)
+	}
+	for i := 0; i < tailBits; i++ {
+		bits = append(bits, true)
+	}
+
+	spb := float64(SampleRate) / Baud
+	total := int(float64(len(bits)) * spb)
+	samples := make([]int16, total)
+	ramp := SampleRate / 200 // 5 ms fade to avoid clicks
+	phase := 0.0
+	for n := range samples {
+		freq := spaceFreq
+		if bits[min(int(float64(n)/spb), len(bits)-1)] {
+			freq = markFreq
+		}
+		phase += 2 * math.Pi * freq / SampleRate
+		gain := amplitude
+		if n < ramp {
+			gain *= float64(n) / float64(ramp)
+		} else if total-n < ramp {
+			gain *= float64(total-n) / float64(ramp)
+		}
+		samples[n] = int16(math.Sin(phase) * gain * math.MaxInt16)
+	}
+	return samples
+}
+
+// Demodulate recovers the payload from samples at the given rate, which need
+// not match SampleRate (e.g. 44.1 kHz recordings)
+func Demodulate(samples []float64, rate int) ([]byte, error) {
+	spb := float64(rate) / Baud
+	window := int(math.Round(spb))
+	if len(samples) < window*10 {
+		return nil, fmt.Errorf("audio is too short to hold a message")
+	}
+
+	// d[n] > 0 when the bit-length window ending at sample n is mostly mark
+	mark := toneEnergy(samples, rate, markFreq, window)
+	space := toneEnergy(samples, rate, spaceFreq, window)
+	d := make([]float64, len(samples))
+	for n := range d {
+		d[n] = mark[n] - space[n]
+	}
+
+	// Each mark->space transition is a candidate start bit; the first one
+	// that yields a sync word and a valid CRC wins
+	for n := window; n < len(d); n++ {
+		if !(d[n-1] > 0 && d[n] <= 0) {
+			continue
+		}
+		start := float64(n) - spb/2
+		payload, err := readFrame(d, start, spb)
+		if err == nil {
+			return payload, nil
+		}
+		if err == errCRC {
+			return nil, fmt.Errorf("audio frame found but the CRC does not match; the recording is too noisy")
+		}
+	}
+	return nil, fmt.Errorf("no text2babe audio signal found")
+}
+
+var errCRC = fmt.Errorf("crc mismatch")
+
+// toneEnergy is the sliding-window energy of one frequency: for every n,
+// |sum of x[k]*e^(-iwk)| over the window ending at n
+func toneEnergy(samples []float64, rate int, freq float64, window int) []float64 {
+	w := 2 * math.Pi * freq / float64(rate)
+	re := make([]float64, len(samples)+1)
+	im := make([]float64, len(samples)+1)
+	for k, x := range samples {
+		re[k+1] = re[k] + x*math.Cos(w*float64(k))
+		im[k+1] = im[k] + x*math.Sin(w*float64(k))
+	}
+
+	energy := make([]float64, len(samples))
+	for n := range samples {
+		lo := max(0, n+1-window)
+		r, i := re[n+1]-re[lo], im[n+1]-im[lo]
+		energy[n] = r*r + i*i
+	}
+	return energy
+}
+
+// bitAt decides the bit occupying [start+i*spb, start+(i+1)*spb)
+func bitAt(d []float64, start, spb float64, i int) (bool, bool) {
+	n := int(start+float64(i+1)*spb) - 1
+	if n < 0 || n >= len(d) {
+		return false, false
+	}
+	return d[n] > 0, true
+}
+
+// readByte decodes one UART byte whose start bit begins at start
+func readByte(d []float64, start, spb float64) (byte, bool) {
+	if bit, ok := bitAt(d, start, spb, 0); !ok || bit {
+		return 0, false
+	}
+	var b byte
+	for i := 0; i < 8; i++ {
+		bit, ok := bitAt(d, start, spb, i+1)
+		if !ok {
+			return 0, false
+		}
+		if bit {
+			b |= 1 << i
+		}
+	}
+	if stop, ok := bitAt(d, start, spb, 9); !ok || !stop {
+		return 0, false
+	}
+	return b, true
+}
+
+// resync moves the expected start of a byte onto the nearest mark->space
+// edge within half a bit, so a sender and recorder whose clocks differ
+// slightly don't drift apart over a long frame
+func resync(d []float64, start, spb float64) float64 {
+	// As in Demodulate, the edge shows up half a bit into the start bit
+	center := int(start + spb/2)
+	for off := 0; off <= int(spb/2); off++ {
+		for _, n := range []int{center - off, center + off} {
+			if n > 0 && n < len(d) && d[n-1] > 0 && d[n] <= 0 {
+				return float64(n) - spb/2
+			}
+		}
+	}
+	return start
+}
+
+func readFrame(d []float64, start, spb float64) ([]byte, error) {
+	pos := start
+	next := func() (byte, error) {
+		b, ok := readByte(d, pos, spb)
+		if !ok {
+			return 0, fmt.Errorf("framing error")
+		}
+		pos = resync(d, pos+10*spb, spb)
+		return b, nil
+	}
+	read := func(n int) ([]byte, error) {
+		out := make([]byte, n)
+		for i := range out {
+			b, err := next()
+			if err != nil {
+				return nil, err
+			}
+			out[i] = b
+		}
+		return out, nil
+	}
+
+	sync, err := read(len(syncWord))
+	if err != nil || !bytes.Equal(sync, syncWord) {
+		return nil, fmt.Errorf("no sync word")
+	}
+	header, err := read(4)
+	if err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(header)
+	if length > maxPayload {
+		return nil, fmt.Errorf("implausible length")
+	}
+	payload, err := read(int(length))
+	if err != nil {
+		return nil, err
+	}
+	crc, err := read(4)
+	if err != nil {
+		return nil, err
+	}
+	if binary.BigEndian.Uint32(crc) != crc32.ChecksumIEEE(payload) {
+		return nil, errCRC
+	}
+	return payload, nil
+}
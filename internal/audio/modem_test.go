@@ -0,0 +1,241 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// toFloat converts modulated samples to the [-1, 1] form Demodulate takes
+func toFloat(samples []int16) []float64 {
+	out := make([]float64, len(samples))
+	for i, s := range samples {
+		out[i] = float64(s) / 32768
+	}
+	return out
+}
+
+// resample converts samples from one rate to another by linear interpolation
+func resample(samples []float64, from, to int) []float64 {
+	out := make([]float64, len(samples)*to/from)
+	for i := range out {
+		pos := float64(i) * float64(from) / float64(to)
+		j := int(pos)
+		if j+1 >= len(samples) {
+			out[i] = samples[len(samples)-1]
+			continue
+		}
+		frac := pos - float64(j)
+		out[i] = samples[j]*(1-frac) + samples[j+1]*frac
+	}
+	return out
+}
+
+func TestModemRoundTrip(t *testing.T) {
+	random := make([]byte, 300)
+	rand.New(rand.NewSource(1)).Read(random)
+	tests := []struct {
+		name    string
+		payload []byte
+	}{
+		{"empty", []byte{}},
+		{"short text", []byte("hi")},
+		{"armored message", []byte("-----BEGIN TEXT2BABE MESSAGE-----\nq4Zbd0cSJ8vx\n=Xx9P\n-----END TEXT2BABE MESSAGE-----")},
+		{"all zero bytes", make([]byte, 64)},
+		{"all one bits", bytes.Repeat([]byte{0xff}, 64)},
+		{"random bytes", random},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(bytes.NewReader(Encode(tt.payload)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.payload) {
+				t.Fatalf("got %q, want %q", got, tt.payload)
+			}
+		})
+	}
+}
+
+func TestModemTolerance(t *testing.T) {
+	// Long enough for clock drift to add up over the frame
+	payload := bytes.Repeat([]byte("meet at the docks at five. "), 8)
+	rng := rand.New(rand.NewSource(2))
+	tests := []struct {
+		name   string
+		rate   int
+		damage func([]float64) []float64
+	}{
+		{"leading silence", SampleRate, func(s []float64) []float64 {
+			return append(make([]float64, SampleRate/2+17), s...)
+		}},
+		{"trailing silence", SampleRate, func(s []float64) []float64 {
+			return append(s, make([]float64, SampleRate)...)
+		}},
+		{"white noise", SampleRate, func(s []float64) []float64 {
+			for i := range s {
+				s[i] += rng.NormFloat64() * 0.1
+			}
+			return s
+		}},
+		{"quiet recording", SampleRate, func(s []float64) []float64 {
+			for i := range s {
+				s[i] *= 0.02
+			}
+			return s
+		}},
+		{"DC offset", SampleRate, func(s []float64) []float64 {
+			for i := range s {
+				s[i] += 0.3
+			}
+			return s
+		}},
+		{"mains hum", SampleRate, func(s []float64) []float64 {
+			for i := range s {
+				s[i] += 0.2 * math.Sin(2*math.Pi*50*float64(i)/SampleRate)
+			}
+			return s
+		}},
+		{"44.1 kHz recording", 44100, func(s []float64) []float64 {
+			return resample(s, SampleRate, 44100)
+		}},
+		{"slow playback clock", SampleRate, func(s []float64) []float64 {
+			return resample(s, SampleRate, SampleRate*101/100)
+		}},
+		{"fast playback clock", SampleRate, func(s []float64) []float64 {
+			return resample(s, SampleRate, SampleRate*99/100)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := tt.damage(toFloat(Modulate(payload)))
+			got, err := Demodulate(samples, tt.rate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, payload) {
+				t.Fatalf("got %q, want %q", got, payload)
+			}
+		})
+	}
+}
+
+// wavHeader builds a WAV header for the given format with dataSize bytes of audio
+func wavHeader(format, channels, bits uint16, dataSize uint32) []byte {
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, 36+dataSize)
+	b.WriteString("WAVEfmt ")
+	for _, v := range []any{
+		uint32(16), format, channels, uint32(SampleRate),
+		uint32(SampleRate) * uint32(channels) * uint32(bits/8), channels * bits / 8, bits,
+	} {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, dataSize)
+	return b.Bytes()
+}
+
+func TestReadWAVFormats(t *testing.T) {
+	payload := []byte("any format will do")
+	samples := toFloat(Modulate(payload))
+	tests := []struct {
+		name     string
+		format   uint16
+		bits     uint16
+		channels uint16
+		encode   func(float64) any
+	}{
+		{"8-bit", 1, 8, 1, func(v float64) any { return uint8(v*127 + 128) }},
+		{"16-bit stereo", 1, 16, 2, func(v float64) any { return int16(v * 32767) }},
+		{"32-bit float", 3, 32, 1, func(v float64) any { return float32(v) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pcm bytes.Buffer
+			for _, v := range samples {
+				for c := uint16(0); c < tt.channels; c++ {
+					binary.Write(&pcm, binary.LittleEndian, tt.encode(v))
+				}
+			}
+			wav := append(wavHeader(tt.format, tt.channels, tt.bits, uint32(pcm.Len())), pcm.Bytes()...)
+			got, err := Decode(bytes.NewReader(wav))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, payload) {
+				t.Fatalf("got %q, want %q", got, payload)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsBadInput(t *testing.T) {
+	valid := Encode([]byte("this message will be cut short"))
+	garbage := make([]byte, 4096)
+	rand.New(rand.NewSource(3)).Read(garbage)
+	noise := make([]int16, SampleRate)
+	rng := rand.New(rand.NewSource(4))
+	for i := range noise {
+		noise[i] = int16(rng.Intn(20000) - 10000)
+	}
+	var noiseWAV bytes.Buffer
+	WriteWAV(&noiseWAV, noise)
+	silence := Encode(nil)[:44]
+	silence = append(silence[:40:40], 0, 0, 0, 0)
+
+	tests := []struct {
+		name string
+		data []byte
+		want string // part of the error message
+	}{
+		{"empty file", nil, "not a WAV file"},
+		{"random bytes", garbage, "not a WAV file"},
+		{"text file", []byte(strings.Repeat("hello ", 100)), "not a WAV file"},
+		{"RIFF header only", valid[:12], "no format information"},
+		{"no data chunk", valid[:36], "no audio data"},
+		{"empty data chunk", silence, "too short"},
+		{"unsupported encoding", append(wavHeader(2, 1, 4, 8), make([]byte, 8)...), "unsupported"},
+		{"truncated mid-message", valid[:len(valid)*2/3], "no text2babe audio signal"},
+		{"noise", noiseWAV.Bytes(), "no text2babe audio signal"},
+		{"RIFF with garbage body", append([]byte("RIFF\x00\x10\x00\x00WAVE"), garbage...), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(bytes.NewReader(tt.data))
+			if err == nil {
+				t.Fatalf("decoded %q from bad input", got)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDemodulateReportsCRCMismatch(t *testing.T) {
+	payload := []byte("flip one bit in the middle")
+	samples := toFloat(Modulate(payload))
+
+	// Swap one payload bit's tone: the byte still frames but the CRC fails
+	spb := SampleRate / Baud
+	bit := preambleBits + (len(syncWord)+4+5)*10 + 3 // a data bit of payload byte 5
+	want := payload[5]>>2&1 == 1
+	freq := markFreq
+	if want {
+		freq = spaceFreq
+	}
+	for n := bit * spb; n < (bit+1)*spb; n++ {
+		samples[n] = amplitude * math.Sin(2*math.Pi*freq*float64(n)/SampleRate)
+	}
+
+	_, err := Demodulate(samples, SampleRate)
+	if err == nil || !strings.Contains(err.Error(), "CRC") {
+		t.Fatalf("got %v, want a CRC error", err)
+	}
+}
@@ -0,0 +1,121 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// WriteWAV writes 16-bit mono PCM samples at SampleRate
+func WriteWAV(w io.Writer, samples []int16) error {
+	dataSize := uint32(len(samples) * 2)
+	header := []any{
+		[]byte("RIFF"), 36 + dataSize, []byte("WAVE"),
+		[]byte("fmt "), uint32(16),
+		uint16(1), uint16(1), // PCM, mono
+		uint32(SampleRate), uint32(SampleRate * 2), // byte rate
+		uint16(2), uint16(16), // block align, bits per sample
+		[]byte("data"), dataSize,
+	}
+	for _, v := range header {
+		if err := binary.Write(w, binary.LittleEndian, v); err != nil {
+			return err
+		}
+	}
+	return binary.Write(w, binary.LittleEndian, samples)
+}
+
+// ReadWAV reads a PCM (8/16/24/32-bit integer or 32-bit float) WAV file,
+// mixing channels down to mono samples in [-1, 1]
+func ReadWAV(r io.Reader) ([]float64, int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, 0, fmt.Errorf("not a WAV file")
+	}
+
+	var format, channels, bits uint16
+	var rate uint32
+	var pcm []byte
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := data[pos+8 : min(pos+8+size, len(data))]
+		switch id {
+		case "fmt ":
+			if len(body) < 16 {
+				return nil, 0, fmt.Errorf("malformed fmt chunk")
+			}
+			format = binary.LittleEndian.Uint16(body[0:2])
+			channels = binary.LittleEndian.Uint16(body[2:4])
+			rate = binary.LittleEndian.Uint32(body[4:8])
+			bits = binary.LittleEndian.Uint16(body[14:16])
+			if format == 0xfffe && len(body) >= 26 {
+				// WAVE_FORMAT_EXTENSIBLE: the real format leads the sub-format GUID
+				format = binary.LittleEndian.Uint16(body[24:26])
+			}
+		case "data":
+			pcm = body
+		}
+		pos += 8 + size + size%2
+	}
+
+	if rate == 0 || channels == 0 {
+		return nil, 0, fmt.Errorf("WAV file has no format information")
+	}
+	if pcm == nil {
+		return nil, 0, fmt.Errorf("WAV file has no audio data")
+	}
+
+	width := int(bits) / 8
+	var decode func([]byte) float64
+	switch {
+	case format == 1 && bits == 8:
+		decode = func(b []byte) float64 { return (float64(b[0]) - 128) / 128 }
+	case format == 1 && bits == 16:
+		decode = func(b []byte) float64 { return float64(int16(binary.LittleEndian.Uint16(b))) / 32768 }
+	case format == 1 && bits == 24:
+		decode = func(b []byte) float64 {
+			v := int32(b[0])<<8 | int32(b[1])<<16 | int32(b[2])<<24
+			return float64(v>>8) / (1 << 23)
+		}
+	case format == 1 && bits == 32:
+		decode = func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31) }
+	case format == 3 && bits == 32:
+		decode = func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) }
+	default:
+		return nil, 0, fmt.Errorf("unsupported WAV encoding (format %d, %d-bit)", format, bits)
+	}
+
+	frame := width * int(channels)
+	samples := make([]float64, len(pcm)/frame)
+	for i := range samples {
+		sum := 0.0
+		for c := 0; c < int(channels); c++ {
+			off := i*frame + c*width
+			sum += decode(pcm[off : off+width])
+		}
+		samples[i] = sum / float64(channels)
+	}
+	return samples, int(rate), nil
+}
+
+// Encode modulates payload into a complete WAV file
+func Encode(payload []byte) []byte {
+	var buf bytes.Buffer
+	WriteWAV(&buf, Modulate(payload))
+	return buf.Bytes()
+}
+
+// Decode demodulates the payload from a WAV file
+func Decode(r io.Reader) ([]byte, error) {
+	samples, rate, err := ReadWAV(r)
+	if err != nil {
+		return nil, err
+	}
+	return Demodulate(samples, rate)
+}
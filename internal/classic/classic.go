@@ -0,0 +1,130 @@
+package classic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Cipher is a reversible classical transform
+type Cipher interface {
+	Name() string
+	Encode(data []byte) ([]byte, error)
+	Decode(data []byte) ([]byte, error)
+	// Textual reports whether the output is printable text that needs no further encoding
+	Textual() bool
+}
+
+// Names lists the supported ciphers and their parameter syntax
+var Names = []string{
+	"caesar:<shift>",
+	"rot13",
+	"rot47",
+	"atbash",
+	"vigenere:<key>",
+	"affine:<a>,<b>",
+	"railfence:<rails>",
+	"playfair:<key>",
+	"xor:<key>",
+	"morse",
+}
+
+// Parse builds a cipher from a spec such as "caesar:3" or "vigenere:lemon"
+func Parse(spec string) (Cipher, error) {
+	name, param, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	name = strings.ToLower(name)
+
+	switch name {
+	case "caesar", "rot":
+		shift, err := strconv.Atoi(param)
+		if err != nil {
+			return nil, fmt.Errorf("caesar needs a numeric shift (caesar:3)")
+		}
+		return Caesar{Shift: shift}, nil
+	case "rot13":
+		return Caesar{Shift: 13}, nil
+	case "rot47":
+		return ROT47{}, nil
+	case "atbash":
+		return Atbash{}, nil
+	case "vigenere", "vigenère":
+		if !hasLetters(param) {
+			return nil, fmt.Errorf("vigenere needs an alphabetic key (vigenere:lemon)")
+		}
+		return Vigenere{Key: param}, nil
+	case "affine":
+		aStr, bStr, _ := strings.Cut(param, ",")
+		a, errA := strconv.Atoi(aStr)
+		b, errB := strconv.Atoi(bStr)
+		if errA != nil || errB != nil {
+			return nil, fmt.Errorf("affine needs two numbers (affine:5,8)")
+		}
+		if gcd(mod(a, 26), 26) != 1 {
+			return nil, fmt.Errorf("affine 'a' must be coprime with 26")
+		}
+		return Affine{A: a, B: b}, nil
+	case "railfence", "rail":
+		rails, err := strconv.Atoi(param)
+		if err != nil || rails < 2 {
+			return nil, fmt.Errorf("railfence needs at least 2 rails (railfence:3)")
+		}
+		return RailFence{Rails: rails}, nil
+	case "playfair":
+		if !hasLetters(param) {
+			return nil, fmt.Errorf("playfair needs an alphabetic key (playfair:monarchy)")
+		}
+		return Playfair{Key: param}, nil
+	case "xor":
+		if param == "" {
+			return nil, fmt.Errorf("xor needs a key (xor:secret)")
+		}
+		return XOR{Key: []byte(param)}, nil
+	case "morse":
+		return Morse{}, nil
+	default:
+		return nil, fmt.Errorf("unknown cipher: %s", name)
+	}
+}
+
+func mod(a, n int) int {
+	return ((a % n) + n) % n
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func hasLetters(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if isLetter(s[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// shiftLetter moves a letter through the alphabet, preserving case
+func shiftLetter(b byte, f func(int) int) byte {
+	switch {
+	case b >= 'a' && b <= 'z':
+		return byte('a' + mod(f(int(b-'a')), 26))
+	case b >= 'A' && b <= 'Z':
+		return byte('A' + mod(f(int(b-'A')), 26))
+	}
+	return b
+}
+
+func mapLetters(data []byte, f func(int) int) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = shiftLetter(b, f)
+	}
+	return out
+}
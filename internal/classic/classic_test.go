@@ -0,0 +1,162 @@
+package classic
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestKnownCiphertexts(t *testing.T) {
+	tests := []struct {
+		spec       string
+		plaintext  string
+		ciphertext string
+		decoded    string // what Decode returns, when the cipher normalizes its input
+	}{
+		{"caesar:3", "Attack at dawn!", "Dwwdfn dw gdzq!", ""},
+		{"caesar:-1", "abc XYZ", "zab WXY", ""},
+		{"rot13", "Hello, World", "Uryyb, Jbeyq", ""},
+		{"rot47", "Hello, World!", "w6==@[ (@C=5P", ""},
+		{"atbash", "Hello", "Svool", ""},
+		{"vigenere:lemon", "ATTACKATDAWN", "LXFOPVEFRNHR", ""},
+		{"affine:5,8", "AFFINECIPHER", "IHHWVCSWFRCP", ""},
+		{"railfence:3", "WEAREDISCOVEREDFLEEATONCE", "WECRLTEERDSOEEFEAOCAIVDEN", ""},
+		{"playfair:playfairexample", "Hide the gold in the tree stump", "BMODZBXDNABEKUDMUIXMMOUVIF", "HIDETHEGOLDINTHETREXESTUMP"},
+		{"xor:k", "a", "\x0a", ""},
+		{"morse", "SOS help", "... --- ... / .... . .-.. .--.", "SOS HELP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			c, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := c.Encode([]byte(tt.plaintext))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.ciphertext {
+				t.Errorf("Encode(%q) = %q, want %q", tt.plaintext, got, tt.ciphertext)
+			}
+
+			want := tt.decoded
+			if want == "" {
+				want = tt.plaintext
+			}
+			back, err := c.Decode([]byte(tt.ciphertext))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(back) != want {
+				t.Errorf("Decode(%q) = %q, want %q", tt.ciphertext, back, want)
+			}
+		})
+	}
+}
+
+// Ciphers that keep non-letters in place decode to exactly what was encoded
+func TestRoundTrip(t *testing.T) {
+	text := []byte("The quick brown fox jumps over the lazy dog. 0123456789 {}[]<>!?\n\tÄ")
+	specs := []string{
+		"caesar:3", "caesar:29", "caesar:-7", "rot13", "rot47", "atbash",
+		"vigenere:lemon", "vigenere:Key With Spaces", "affine:5,8", "affine:25,0",
+		"railfence:2", "railfence:3", "railfence:7", "railfence:100", "xor:secret",
+	}
+	for _, spec := range specs {
+		t.Run(spec, func(t *testing.T) {
+			c, err := Parse(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, data := range [][]byte{text, {}, {0x00, 0xff, 'a'}} {
+				encoded, err := c.Encode(data)
+				if err != nil {
+					t.Fatal(err)
+				}
+				decoded, err := c.Decode(encoded)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(decoded, data) {
+					t.Errorf("round trip of %q gave %q", data, decoded)
+				}
+			}
+
+			// The name parses back to the same cipher
+			again, err := Parse(c.Name())
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", c.Name(), err)
+			}
+			if again.Name() != c.Name() {
+				t.Errorf("Parse(%q).Name() = %q", c.Name(), again.Name())
+			}
+		})
+	}
+}
+
+func TestPlayfairRoundTrip(t *testing.T) {
+	c := Playfair{Key: "monarchy"}
+	for _, text := range []string{"instruments", "balloon", "XX", "a", "jazz"} {
+		encoded, _ := c.Encode([]byte(text))
+		decoded, _ := c.Decode(encoded)
+		// Decoding yields the padded digraphs; removing the padding X/Q gives
+		// back the letters (with J folded into I)
+		want := strings.ReplaceAll(strings.ToUpper(text), "J", "I")
+		if got := strings.NewReplacer("X", "", "Q", "").Replace(string(decoded)); got != strings.NewReplacer("X", "", "Q", "").Replace(want) {
+			t.Errorf("%q: decoded %q", text, decoded)
+		}
+		if len(encoded)%2 != 0 {
+			t.Errorf("%q: odd ciphertext length %d", text, len(encoded))
+		}
+	}
+}
+
+func TestMorseRejects(t *testing.T) {
+	if _, err := (Morse{}).Encode([]byte("snow ☃")); err == nil {
+		t.Error("encoded a character with no Morse code")
+	}
+	if _, err := (Morse{}).Decode([]byte("... ---- ...")); err == nil {
+		t.Error("decoded an invalid Morse sequence")
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string // part of the error message
+	}{
+		{"caesar", "numeric shift"},
+		{"caesar:three", "numeric shift"},
+		{"vigenere:1234", "alphabetic key"},
+		{"vigenere", "alphabetic key"},
+		{"affine:5", "two numbers"},
+		{"affine:2,3", "coprime"},
+		{"affine:13,1", "coprime"},
+		{"railfence:1", "at least 2 rails"},
+		{"railfence:x", "at least 2 rails"},
+		{"playfair:", "alphabetic key"},
+		{"xor", "needs a key"},
+		{"enigma", "unknown cipher"},
+		{"", "unknown cipher"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := Parse(tt.spec)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want an error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIsCaseInsensitive(t *testing.T) {
+	for _, spec := range []string{"ROT13", " Caesar:13 ", "rot:13"} {
+		c, err := Parse(spec)
+		if err != nil {
+			t.Fatalf("%q: %v", spec, err)
+		}
+		if c.Name() != "rot13" {
+			t.Errorf("%q parsed as %s", spec, c.Name())
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package classic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Caesar shifts letters by a fixed amount (ROT13 is a shift of 13)
+type Caesar struct {
+	Shift int
+}
+
+func (c Caesar) Name() string {
+	if c.Shift == 13 {
+		return "rot13"
+	}
+	return fmt.Sprintf("caesar:%d", c.Shift)
+}
+
+func (c Caesar) Encode(data []byte) ([]byte, error) {
+	return mapLetters(data, func(x int) int { return x + c.Shift }), nil
+}
+
+func (c Caesar) Decode(data []byte) ([]byte, error) {
+	return mapLetters(data, func(x int) int { return x - c.Shift }), nil
+}
+
+func (Caesar) Textual() bool { return true }
+
+// ROT47 rotates all printable ASCII characters
+type ROT47 struct{}
+
+func (ROT47) Name() string { return "rot47" }
+
+func (ROT47) Encode(data []byte) ([]byte, error) {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		if b >= '!' && b <= '~' {
+			b = '!' + (b-'!'+47)%94
+		}
+		out[i] = b
+	}
+	return out, nil
+}
+
+// ROT47 is its own inverse
+func (r ROT47) Decode(data []byte) ([]byte, error) { return r.Encode(data) }
+
+func (ROT47) Textual() bool { return true }
+
+// Atbash mirrors the alphabet (A<->Z)
+type Atbash struct{}
+
+func (Atbash) Name() string { return "atbash" }
+
+func (Atbash) Encode(data []byte) ([]byte, error) {
+	return mapLetters(data, func(x int) int { return 25 - x }), nil
+}
+
+func (a Atbash) Decode(data []byte) ([]byte, error) { return a.Encode(data) }
+
+func (Atbash) Textual() bool { return true }
+
+// Vigenere shifts each letter by the matching letter of a repeating key
+type Vigenere struct {
+	Key string
+}
+
+func (v Vigenere) Name() string { return "vigenere:" + v.Key }
+
+func (v Vigenere) shifts() []int {
+	var shifts []int
+	for i := 0; i < len(v.Key); i++ {
+		if b := v.Key[i]; isLetter(b) {
+			shifts = append(shifts, int(b|0x20)-'a')
+		}
+	}
+	return shifts
+}
+
+func (v Vigenere) apply(data []byte, sign int) []byte {
+	shifts := v.shifts()
+	out := make([]byte, len(data))
+	n := 0
+	for i, b := range data {
+		if !isLetter(b) {
+			out[i] = b
+			continue
+		}
+		shift := shifts[n%len(shifts)] * sign
+		out[i] = shiftLetter(b, func(x int) int { return x + shift })
+		n++
+	}
+	return out
+}
+
+func (v Vigenere) Encode(data []byte) ([]byte, error) { return v.apply(data, 1), nil }
+
+func (v Vigenere) Decode(data []byte) ([]byte, error) { return v.apply(data, -1), nil }
+
+func (Vigenere) Textual() bool { return true }
+
+// Affine maps each letter x to (a*x + b) mod 26
+type Affine struct {
+	A, B int
+}
+
+func (a Affine) Name() string { return fmt.Sprintf("affine:%d,%d", a.A, a.B) }
+
+func (a Affine) Encode(data []byte) ([]byte, error) {
+	return mapLetters(data, func(x int) int { return a.A*x + a.B }), nil
+}
+
+func (a Affine) Decode(data []byte) ([]byte, error) {
+	inverse := 0
+	for i := 1; i < 26; i++ {
+		if mod(a.A*i, 26) == 1 {
+			inverse = i
+			break
+		}
+	}
+	if inverse == 0 {
+		return nil, fmt.Errorf("affine 'a' must be coprime with 26")
+	}
+	return mapLetters(data, func(x int) int { return inverse * (x - a.B) }), nil
+}
+
+func (Affine) Textual() bool { return true }
+
+// Playfair encrypts letter pairs using a 5x5 key square (I and J share a cell).
+// Non-letters are dropped and the output is upper case, as is traditional.
+type Playfair struct {
+	Key string
+}
+
+func (p Playfair) Name() string { return "playfair:" + p.Key }
+
+func (p Playfair) square() (grid [25]byte, pos [26]int) {
+	seen := [26]bool{}
+	n := 0
+	add := func(b byte) {
+		if b == 'J' {
+			b = 'I'
+		}
+		if idx := b - 'A'; !seen[idx] {
+			seen[idx] = true
+			grid[n] = b
+			pos[idx] = n
+			n++
+		}
+	}
+	for _, b := range []byte(strings.ToUpper(p.Key)) {
+		if b >= 'A' && b <= 'Z' {
+			add(b)
+		}
+	}
+	for b := byte('A'); b <= 'Z'; b++ {
+		add(b)
+	}
+	pos['J'-'A'] = pos['I'-'A']
+	return grid, pos
+}
+
+// playfairPairs splits letters into digraphs, separating doubled letters and
+// padding an odd tail with X (or Q when the letter itself is X)
+func playfairPairs(data []byte) [][2]byte {
+	var letters []byte
+	for _, b := range []byte(strings.ToUpper(string(data))) {
+		if b >= 'A' && b <= 'Z' {
+			if b == 'J' {
+				b = 'I'
+			}
+			letters = append(letters, b)
+		}
+	}
+
+	var pairs [][2]byte
+	for i := 0; i < len(letters); {
+		a := letters[i]
+		b := byte('X')
+		if a == 'X' {
+			b = 'Q'
+		}
+		if i+1 < len(letters) && letters[i+1] != a {
+			b = letters[i+1]
+			i += 2
+		} else {
+			i++
+		}
+		pairs = append(pairs, [2]byte{a, b})
+	}
+	return pairs
+}
+
+func (p Playfair) apply(data []byte, step int) []byte {
+	grid, pos := p.square()
+	var out []byte
+	for _, pair := range playfairPairs(data) {
+		a, b := pos[pair[0]-'A'], pos[pair[1]-'A']
+		ra, ca, rb, cb := a/5, a%5, b/5, b%5
+		switch {
+		case ra == rb:
+			ca, cb = mod(ca+step, 5), mod(cb+step, 5)
+		case ca == cb:
+			ra, rb = mod(ra+step, 5), mod(rb+step, 5)
+		default:
+			ca, cb = cb, ca
+		}
+		out = append(out, grid[ra*5+ca], grid[rb*5+cb])
+	}
+	return out
+}
+
+func (p Playfair) Encode(data []byte) ([]byte, error) { return p.apply(data, 1), nil }
+
+func (p Playfair) Decode(data []byte) ([]byte, error) { return p.apply(data, -1), nil }
+
+func (Playfair) Textual() bool { return true }
@@ -0,0 +1,139 @@
+package classic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RailFence writes characters in a zigzag across a number of rails and reads them row by row
+type RailFence struct {
+	Rails int
+}
+
+func (r RailFence) Name() string { return fmt.Sprintf("railfence:%d", r.Rails) }
+
+// order lists message positions in the order they are read off the rails
+func (r RailFence) order(n int) []int {
+	rails := make([]int, n)
+	rail, step := 0, 1
+	for i := range rails {
+		rails[i] = rail
+		if rail == 0 {
+			step = 1
+		} else if rail == r.Rails-1 {
+			step = -1
+		}
+		rail += step
+	}
+
+	order := make([]int, 0, n)
+	for rail := 0; rail < r.Rails; rail++ {
+		for i, ri := range rails {
+			if ri == rail {
+				order = append(order, i)
+			}
+		}
+	}
+	return order
+}
+
+func (r RailFence) Encode(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	for _, i := range r.order(len(data)) {
+		out = append(out, data[i])
+	}
+	return out, nil
+}
+
+func (r RailFence) Decode(data []byte) ([]byte, error) {
+	out := make([]byte, len(data))
+	for j, i := range r.order(len(data)) {
+		out[i] = data[j]
+	}
+	return out, nil
+}
+
+func (RailFence) Textual() bool { return true }
+
+// XOR combines every byte with a repeating key. The result is binary, so it
+// still goes through the configured output format.
+type XOR struct {
+	Key []byte
+}
+
+func (x XOR) Name() string { return "xor:" + string(x.Key) }
+
+func (x XOR) Encode(data []byte) ([]byte, error) {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = b ^ x.Key[i%len(x.Key)]
+	}
+	return out, nil
+}
+
+func (x XOR) Decode(data []byte) ([]byte, error) { return x.Encode(data) }
+
+func (XOR) Textual() bool { return false }
+
+// Morse uses International Morse code with spaces between letters and " / " between words
+type Morse struct{}
+
+var morseTable = map[byte]string{
+	'A': ".-", 'B': "-...", 'C': "-.-.", 'D': "-..", 'E': ".", 'F': "..-.",
+	'G': "--.", 'H': "....", 'I': "..", 'J': ".---", 'K': "-.-", 'L': ".-..",
+	'M': "--", 'N': "-.", 'O': "---", 'P': ".--.", 'Q': "--.-", 'R': ".-.",
+	'S': "...", 'T': "-", 'U': "..-", 'V': "...-", 'W': ".--", 'X': "-..-",
+	'Y': "-.--", 'Z': "--..",
+	'0': "-----", '1': ".----", '2': "..---", '3': "...--", '4': "....-",
+	'5': ".....", '6': "-....", '7': "--...", '8': "---..", '9': "----.",
+	'.': ".-.-.-", ',': "--..--", '?': "..--..", '\'': ".----.", '!': "-.-.--",
+	'/': "-..-.", '(': "-.--.", ')': "-.--.-", '&': ".-...", ':': "---...",
+	';': "-.-.-.", '=': "-...-", '+': ".-.-.", '-': "-....-", '_': "..--.-",
+	'"': ".-..-.", '$': "...-..-", '@': ".--.-.",
+}
+
+var morseReverse = func() map[string]byte {
+	m := make(map[string]byte, len(morseTable))
+	for k, v := range morseTable {
+		m[v] = k
+	}
+	return m
+}()
+
+func (Morse) Name() string { return "morse" }
+
+func (Morse) Encode(data []byte) ([]byte, error) {
+	var words []string
+	for _, word := range strings.Fields(strings.ToUpper(string(data))) {
+		var letters []string
+		for i := 0; i < len(word); i++ {
+			code, ok := morseTable[word[i]]
+			if !ok {
+				return nil, fmt.Errorf("character %q has no Morse code", word[i])
+			}
+			letters = append(letters, code)
+		}
+		words = append(words, strings.Join(letters, " "))
+	}
+	return []byte(strings.Join(words, " / ")), nil
+}
+
+func (Morse) Decode(data []byte) ([]byte, error) {
+	var words []string
+	for _, word := range strings.Split(string(data), "/") {
+		var letters []byte
+		for _, code := range strings.Fields(word) {
+			b, ok := morseReverse[code]
+			if !ok {
+				return nil, fmt.Errorf("invalid Morse sequence %q", code)
+			}
+			letters = append(letters, b)
+		}
+		if len(letters) > 0 {
+			words = append(words, string(letters))
+		}
+	}
+	return []byte(strings.Join(words, " ")), nil
+}
+
+func (Morse) Textual() bool { return true }
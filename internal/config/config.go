@@ -2,31 +2,100 @@ package config
 
 import (
 	"crypto/sha256"
+	"doc0x1/text2babe/internal/classic"
 	"doc0x1/text2babe/internal/discord"
 	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
 )
 
 type Config struct {
 	Mode          string
 	DataType      string
 	OutputMode    string
+	InputFormat   string // Input format for decrypt ("auto" detects it)
 	Key           []byte
 	KeySource     string // Track what password/source was used
 	Discord       *discord.Client
-	SendToDiscord bool // Toggle for Discord sending
-	UseEncryption bool // Toggle for AES encryption vs plain encoding
+	SendToDiscord bool              // Toggle for Discord sending
+	UseEncryption bool              // Toggle for AES encryption vs plain encoding
+	ShowQR        bool              // Render encrypted output as a terminal QR code
+	StegoCover    string            // Cover text for zero-width steganography (empty = off)
+	Classic       string            // Classical cipher spec used in plain mode (empty = off)
+	Recipes       map[string]string // Named transform pipelines, stored in the config file
+	Recipe        string            // Active recipe name (empty = off)
+	FEC           int               // Reed-Solomon parity bytes per 255-byte block (0 = off)
+	Cipher        string            // Authenticated cipher used with encryption on
+	Clipboard     bool              // Copy results to the clipboard
+	Profile       string            // Active profile name (empty = none)
+	KeyRef        string            // Where the key was loaded from, e.g. "file:<path>" (empty for typed passwords)
+	Sources       map[string]Source // Where each changed setting came from
+	EnvOverrides  []string          // Environment variables that changed a setting
+	Warnings      []string          // Problems found while loading the config file or environment
+
+	keyCommand string // Key command that hasn't run yet (see LoadKey)
+}
+
+// Ciphers maps the supported authenticated ciphers to their display names
+var Ciphers = map[string]string{
+	"aes-gcm":           "AES-256-GCM",
+	"chacha20-poly1305": "ChaCha20-Poly1305",
+}
+
+// CipherByName returns the cipher for a setting value or display name,
+// or "" if it is not supported
+func CipherByName(name string) string {
+	name = strings.ToLower(name)
+	switch name {
+	case "aes", "aes-256-gcm":
+		return "aes-gcm"
+	case "chacha", "chacha20", "chacha20poly1305":
+		return "chacha20-poly1305"
+	}
+	if _, ok := Ciphers[name]; ok {
+		return name
+	}
+	return ""
 }
 
+// FECLevels maps the named redundancy levels to parity bytes per block
+var FECLevels = map[string]int{
+	"low":    16,
+	"medium": 32,
+	"high":   64,
+}
+
+// New returns the defaults overridden by the config file, then by the
+// environment. A .env file in the working directory is loaded into the
+// environment first; variables that are already set win over it.
 func New() *Config {
+	_ = godotenv.Load() // the .env file is optional
+	c := newDefaults()
+	c.loadFile()
+	c.loadEnv()
+	return c
+}
+
+func newDefaults() *Config {
 	return &Config{
 		Mode:          "encrypt",
 		DataType:      "text",
 		OutputMode:    "hex",
+		InputFormat:   "auto",
 		Key:           generateKey("default-password"),
 		KeySource:     "default-password",
 		Discord:       nil, // Initialize lazily
 		SendToDiscord: true,
 		UseEncryption: false, // Default to encryption disabled
+		ShowQR:        false,
+		Cipher:        "aes-gcm",
+		Clipboard:     true,
+		Recipes:       map[string]string{},
+		Sources:       map[string]Source{},
 	}
 }
 
@@ -47,13 +116,78 @@ func (c *Config) SetDataType(dataType string) bool {
 }
 
 func (c *Config) SetOutputMode(outputMode string) bool {
-	if outputMode == "hex" || outputMode == "base64" || outputMode == "binary" {
+	switch outputMode {
+	case "hex", "base64", "binary", "armor", "hexdump", "url", "html", "unicode", "qp":
 		c.OutputMode = outputMode
 		return true
 	}
 	return false
 }
 
+func (c *Config) SetInputFormat(format string) bool {
+	switch format {
+	case "auto", "armor", "hexdump", "hex", "base64", "binary", "url", "html", "unicode", "qp", "text":
+		c.InputFormat = format
+		return true
+	}
+	return false
+}
+
+// SetFEC sets the Reed-Solomon redundancy from a level name, a parity byte
+// count, or "off"
+func (c *Config) SetFEC(value string) error {
+	value = strings.ToLower(value)
+	if value == "off" || value == "none" || value == "0" {
+		c.FEC = 0
+		return nil
+	}
+	if n, ok := FECLevels[value]; ok {
+		c.FEC = n
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 2 || n > 128 {
+		return fmt.Errorf("fec must be off, low, medium, high or a parity count from 2 to 128")
+	}
+	c.FEC = n
+	return nil
+}
+
+// SetCipher selects the authenticated cipher used with encryption on
+func (c *Config) SetCipher(name string) bool {
+	if cipher := CipherByName(name); cipher != "" {
+		c.Cipher = cipher
+		return true
+	}
+	return false
+}
+
+// SetClassic selects a classical cipher for plain mode ("off" clears it)
+func (c *Config) SetClassic(spec string) error {
+	if spec == "" || spec == "off" || spec == "none" {
+		c.Classic = ""
+		return nil
+	}
+	if _, err := classic.Parse(spec); err != nil {
+		return err
+	}
+	c.Classic = spec
+	return nil
+}
+
+// ClassicCipher returns the active classical cipher, or nil when encryption
+// is on or no cipher is selected
+func (c *Config) ClassicCipher() classic.Cipher {
+	if c.UseEncryption || c.Classic == "" {
+		return nil
+	}
+	cipher, err := classic.Parse(c.Classic)
+	if err != nil {
+		return nil
+	}
+	return cipher
+}
+
 func (c *Config) ToggleMode() {
 	if c.Mode == "encrypt" {
 		c.Mode = "decrypt"
@@ -77,6 +211,10 @@ func (c *Config) ToggleOutputMode() {
 	case "base64":
 		c.OutputMode = "binary"
 	case "binary":
+		c.OutputMode = "armor"
+	case "armor":
+		c.OutputMode = "hexdump"
+	case "hexdump":
 		c.OutputMode = "hex"
 	default:
 		c.OutputMode = "hex"
@@ -86,14 +224,35 @@ func (c *Config) ToggleOutputMode() {
 func (c *Config) SetKey(password string) {
 	c.Key = generateKey(password)
 	c.KeySource = password
+	c.KeyRef = ""
+	c.keyCommand = ""
+}
+
+// SetRawKey uses a 32-byte key directly, e.g. one restored from a backup
+func (c *Config) SetRawKey(key []byte, source string) {
+	c.Key = key
+	c.KeySource = source
+	c.KeyRef = ""
+	c.keyCommand = ""
 }
 
 // GetKeyFingerprint returns a short hex representation of the key for display
 func (c *Config) GetKeyFingerprint() string {
-	if len(c.Key) >= 4 {
-		return hex.EncodeToString(c.Key[:4]) + "..."
+	if len(c.Key) == 0 {
+		return "unknown"
+	}
+	return c.KeyID() + "..."
+}
+
+// KeyID identifies the key in armored messages, JSON output and paper
+// backups. It is hashed separately from the key so publishing it reveals
+// nothing about the key bytes.
+func (c *Config) KeyID() string {
+	if len(c.Key) == 0 {
+		return "unknown"
 	}
-	return "unknown"
+	sum := sha256.Sum256(append([]byte("text2babe key-id"), c.Key...))
+	return hex.EncodeToString(sum[:4])
 }
 
 // IsDefaultKey returns true if using the default password
@@ -175,3 +334,68 @@ func generateKey(password string) []byte {
 	hash := sha256.Sum256([]byte(password))
 	return hash[:]
 }
+
+// SetRecipe stores a named recipe spec in memory; call SaveRecipes to persist it
+func (c *Config) SetRecipe(name, spec string) {
+	c.Recipes[name] = spec
+}
+
+// DeleteRecipe removes a recipe, deactivating it if it was in use
+func (c *Config) DeleteRecipe(name string) bool {
+	if _, ok := c.Recipes[name]; !ok {
+		return false
+	}
+	delete(c.Recipes, name)
+	if c.Recipe == name {
+		c.Recipe = ""
+	}
+	return true
+}
+
+// RecipeNames returns the stored recipe names in sorted order
+func (c *Config) RecipeNames() []string {
+	names := make([]string, 0, len(c.Recipes))
+	for name := range c.Recipes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadFile applies the config file's recipes and settings
+func (c *Config) loadFile() {
+	path, err := FilePath()
+	if err != nil {
+		return
+	}
+	data, err := readFile(path)
+	if err != nil {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("config file ignored: %v", err))
+		return
+	}
+	c.loadRecipes(data)
+	c.loadSettings(data)
+}
+
+func (c *Config) loadRecipes(data fileData) {
+	for name, spec := range data["recipes"] {
+		c.Recipes[name] = spec
+	}
+}
+
+// SaveRecipes writes the recipes section of the config file, leaving other sections untouched
+func (c *Config) SaveRecipes() error {
+	path, err := FilePath()
+	if err != nil {
+		return err
+	}
+	data, err := readFile(path)
+	if err != nil {
+		return fmt.Errorf("not overwriting unreadable config file: %w", err)
+	}
+	data["recipes"] = map[string]string{}
+	for name, spec := range c.Recipes {
+		data["recipes"][name] = spec
+	}
+	return writeFile(path, data)
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// testConfig returns a config with its files in a fresh directory
+func testConfig(t *testing.T) *Config {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	return New()
+}
+
+func TestKeyID(t *testing.T) {
+	c := testConfig(t)
+	c.SetKey("correct horse battery staple")
+	id := c.KeyID()
+	if len(id) != 8 {
+		t.Fatalf("KeyID %q is not 4 hex bytes", id)
+	}
+	if id == hex.EncodeToString(c.Key[:4]) {
+		t.Error("KeyID publishes the first key bytes")
+	}
+	if got := c.GetKeyFingerprint(); got != id+"..." {
+		t.Errorf("fingerprint %q does not match key ID %q", got, id)
+	}
+
+	c.SetKey("correct horse battery staple")
+	if c.KeyID() != id {
+		t.Error("KeyID changed for the same key")
+	}
+	c.SetKey("another password")
+	if c.KeyID() == id {
+		t.Error("different keys have the same KeyID")
+	}
+
+	c.Key = nil
+	if got := c.GetKeyFingerprint(); !strings.Contains(got, "unknown") {
+		t.Errorf("fingerprint without a key is %q", got)
+	}
+}
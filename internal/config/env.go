@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+// EnvVars maps environment variables onto settings. They override the
+// config file and are overridden by flags. The profile comes first so the
+// other variables can override it.
+var EnvVars = []struct {
+	Name    string
+	Setting string
+	Negate  bool   // a true value turns the setting off, e.g. TEXT2BABE_NO_CLIPBOARD
+	Prefix  string // prepended to the value, for key references
+}{
+	{"TEXT2BABE_PROFILE", "profile", false, ""},
+	{"TEXT2BABE_OUTPUT", "output", false, ""},
+	{"TEXT2BABE_INPUT", "input", false, ""},
+	{"TEXT2BABE_DATATYPE", "datatype", false, ""},
+	{"TEXT2BABE_ENCRYPTION", "encryption", false, ""},
+	{"TEXT2BABE_CIPHER", "cipher", false, ""},
+	{"TEXT2BABE_FEC", "fec", false, ""},
+	{"TEXT2BABE_CLASSIC", "classic", false, ""},
+	{"TEXT2BABE_RECIPE", "recipe", false, ""},
+	{"TEXT2BABE_QR", "qr", false, ""},
+	{"TEXT2BABE_CLIPBOARD", "clipboard", false, ""},
+	{"TEXT2BABE_NO_CLIPBOARD", "clipboard", true, ""},
+	{"TEXT2BABE_DISCORD", "discord", false, ""},
+	{"TEXT2BABE_NO_DISCORD", "discord", true, ""},
+	{"TEXT2BABE_KEY_FILE", "key", false, "file:"},
+	{"TEXT2BABE_KEY_COMMAND", "key", false, "command:"},
+	{"DISCORD_DM_ID", "discord-id", false, ""},
+}
+
+// credentialVars hold Discord credentials, which were read from the
+// environment (or .env) long before the TEXT2BABE_* variables. They are set
+// in every Discord setup, so they aren't reported as overrides.
+var credentialVars = map[string]bool{
+	"DISCORD_DM_ID": true,
+}
+
+// loadEnv applies the environment variables that are set. Invalid values
+// are skipped and reported in Warnings.
+func (c *Config) loadEnv() {
+	for _, v := range EnvVars {
+		value, ok := os.LookupEnv(v.Name)
+		if !ok || value == "" {
+			continue
+		}
+		if v.Negate {
+			on, err := parseSwitch(v.Name, value)
+			if err != nil {
+				c.Warnings = append(c.Warnings, err.Error())
+				continue
+			}
+			if !on {
+				continue
+			}
+			value = "off"
+		}
+		value = v.Prefix + value
+		if err := c.Set(v.Setting, value, SourceEnv); err != nil {
+			c.Warnings = append(c.Warnings, fmt.Sprintf("%s: %v", v.Name, err))
+			continue
+		}
+		if !credentialVars[v.Name] {
+			c.EnvOverrides = append(c.EnvOverrides, v.Name)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// The environment overrides the config file and flags override the environment
+func TestEnvPrecedence(t *testing.T) {
+	writeConfig(t, `[settings]
+output = "base64"
+cipher = "chacha20-poly1305"
+clipboard = true
+`)
+	t.Setenv("TEXT2BABE_OUTPUT", "armor")
+	t.Setenv("TEXT2BABE_NO_CLIPBOARD", "1")
+	t.Setenv("TEXT2BABE_ENCRYPTION", "on")
+	c := New()
+	if len(c.Warnings) > 0 {
+		t.Fatalf("warnings: %v", c.Warnings)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		src   Source
+	}{
+		{"output", "armor", SourceEnv},
+		{"clipboard", "false", SourceEnv},
+		{"encryption", "true", SourceEnv},
+		{"cipher", "chacha20-poly1305", SourceFile},
+	}
+	for _, tt := range tests {
+		if got := c.Get(tt.name); got != tt.value {
+			t.Errorf("%s = %q, want %q", tt.name, got, tt.value)
+		}
+		if got := c.SourceOf(tt.name); got != tt.src {
+			t.Errorf("%s comes from %s, want %s", tt.name, got, tt.src)
+		}
+	}
+
+	if err := c.Set("output", "hex", SourceFlag); err != nil {
+		t.Fatal(err)
+	}
+	if c.OutputMode != "hex" || c.SourceOf("output") != SourceFlag {
+		t.Errorf("flag gave output %s from %s", c.OutputMode, c.SourceOf("output"))
+	}
+}
+
+func TestEnvOverridesReport(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("DISCORD_DM_ID", "123456789")
+	t.Setenv("TEXT2BABE_CIPHER", "chacha")
+	t.Setenv("TEXT2BABE_NO_DISCORD", "false") // off: leaves auto-send alone
+	c := New()
+	if !slices.Equal(c.EnvOverrides, []string{"TEXT2BABE_CIPHER"}) {
+		t.Errorf("EnvOverrides = %v, want only TEXT2BABE_CIPHER", c.EnvOverrides)
+	}
+	if c.SourceOf("discord-id") != SourceEnv || c.Get("discord-id") != "123456789" {
+		t.Errorf("discord-id %q from %s", c.Get("discord-id"), c.SourceOf("discord-id"))
+	}
+	if c.SourceOf("discord") != SourceDefault {
+		t.Errorf("discord comes from %s", c.SourceOf("discord"))
+	}
+}
+
+func TestEnvWarnings(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("DISCORD_DM_ID", "")
+	t.Setenv("TEXT2BABE_OUTPUT", "morse")
+	t.Setenv("TEXT2BABE_NO_CLIPBOARD", "maybe")
+	c := New()
+	if c.OutputMode != "hex" || !c.Clipboard || len(c.EnvOverrides) != 0 {
+		t.Errorf("invalid values applied: output %s, clipboard %v, overrides %v", c.OutputMode, c.Clipboard, c.EnvOverrides)
+	}
+	warnings := strings.Join(c.Warnings, "\n")
+	for _, want := range []string{"TEXT2BABE_OUTPUT: output mode must be", "TEXT2BABE_NO_CLIPBOARD must be"} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("warnings %q do not mention %q", warnings, want)
+		}
+	}
+}
+
+// Saving settings keeps the file's value for settings the environment overrides
+func TestSaveSettingsSkipsEnv(t *testing.T) {
+	writeConfig(t, `[settings]
+output = "base64"
+`)
+	t.Setenv("TEXT2BABE_OUTPUT", "armor")
+	t.Setenv("TEXT2BABE_QR", "on")
+	c := New()
+	c.Set("cipher", "chacha", SourceShell)
+	if err := c.SaveSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	path, _ := FilePath()
+	data, err := readFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"output": "base64", "cipher": "chacha20-poly1305"}
+	if len(data["settings"]) != len(want) {
+		t.Errorf("saved %v, want %v", data["settings"], want)
+	}
+	for name, value := range want {
+		if data["settings"][name] != value {
+			t.Errorf("saved %s = %q, want %q", name, data["settings"][name], value)
+		}
+	}
+}
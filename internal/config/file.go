@@ -0,0 +1,209 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The config file is a small TOML subset: [section] headers and
+// key = value lines, where values are basic strings, booleans or integers.
+
+// fileData maps section name -> key -> value (strings unquoted)
+type fileData map[string]map[string]string
+
+// FilePath returns $XDG_CONFIG_HOME/text2babe/config.toml, falling back to
+// the platform's user config directory
+func FilePath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		var err error
+		if dir, err = os.UserConfigDir(); err != nil {
+			return "", fmt.Errorf("cannot locate config directory: %w", err)
+		}
+	}
+	return filepath.Join(dir, "text2babe", "config.toml"), nil
+}
+
+// FirstRun reports whether the config file has not been created yet
+func FirstRun() bool {
+	path, err := FilePath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return os.IsNotExist(err)
+}
+
+// EnsureFile creates an empty config file if there is none, so FirstRun
+// reports false from then on
+func EnsureFile() error {
+	if !FirstRun() {
+		return nil
+	}
+	path, err := FilePath()
+	if err != nil {
+		return err
+	}
+	return writeFile(path, fileData{})
+}
+
+// readFile parses the config file; a missing file is not an error
+func readFile(path string) (fileData, error) {
+	data := fileData{}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		// A quoted key may itself contain '='
+		keyEnd := 0
+		if strings.HasPrefix(line, `"`) {
+			keyEnd = closingQuote(line) + 1
+		}
+		i := strings.Index(line[keyEnd:], "=")
+		if i < 0 {
+			return nil, fmt.Errorf("%s:%d: expected key = value", path, lineNo)
+		}
+		key, value := strings.TrimSpace(line[:keyEnd+i]), line[keyEnd+i+1:]
+		if unquoted, err := strconv.Unquote(key); err == nil {
+			key = unquoted
+		}
+		parsed, err := parseValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+
+		if data[section] == nil {
+			data[section] = map[string]string{}
+		}
+		data[section][key] = parsed
+	}
+	return data, scanner.Err()
+}
+
+func parseValue(value string) (string, error) {
+	if strings.HasPrefix(value, `"`) {
+		end := closingQuote(value)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string %s", value)
+		}
+		// Only a comment may follow the closing quote
+		if rest := strings.TrimSpace(value[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected %q after string", rest)
+		}
+		unquoted, err := strconv.Unquote(value[:end+1])
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s", value)
+		}
+		return unquoted, nil
+	}
+	if i := strings.Index(value, "#"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	if value == "" {
+		return "", fmt.Errorf("missing value")
+	}
+	return value, nil
+}
+
+// closingQuote returns the index of the quote that ends the basic string
+// starting at s[0], skipping escaped characters, or -1 if it is unterminated
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// writeFile saves data with sections and keys in a stable order. In the
+// [settings] and profile sections, the settings listed in bareSettings are
+// written unquoted (booleans and numbers); recipes are always quoted.
+func writeFile(path string, data fileData) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("# text2babe configuration\n")
+
+	sections := make([]string, 0, len(data))
+	for name := range data {
+		sections = append(sections, name)
+	}
+	sort.Strings(sections)
+
+	for _, section := range sections {
+		values := data[section]
+		if len(values) == 0 {
+			continue
+		}
+		if section != "" {
+			fmt.Fprintf(&b, "\n[%s]\n", section)
+		}
+		settings := section == "settings" || strings.HasPrefix(section, profilePrefix)
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			name := k
+			if strings.ContainsAny(k, " =.\"#[]") {
+				name = quote(k)
+			}
+			value := quote(values[k])
+			if settings && bareSettings[k] {
+				value = values[k]
+			}
+			fmt.Fprintf(&b, "%s = %s\n", name, value)
+		}
+	}
+
+	return os.WriteFile(path, []byte(b.String()), 0o600)
+}
+
+// quote produces a TOML basic string
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{`"hex"`, "hex"},
+		{`"a" # "x"`, "a"},
+		{`"a#b" # comment`, "a#b"},
+		{`"say \"hi\"" # "quoted" comment`, `say "hi"`},
+		{`"back\\slash\\"`, `back\slash\`},
+		{`"tab\there"`, "tab\there"},
+		{`"é"`, "é"},
+		{`""`, ""},
+		{`true`, "true"},
+		{`32 # parity bytes`, "32"},
+		{`aes-gcm#comment`, "aes-gcm"},
+	}
+	for _, tt := range tests {
+		got, err := parseValue(tt.value)
+		if err != nil {
+			t.Errorf("parseValue(%s): %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseValue(%s) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseValueRejects(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string // part of the error message
+	}{
+		{`"unterminated`, "unterminated string"},
+		{`"escaped end\"`, "unterminated string"},
+		{`"a" "b"`, "after string"},
+		{`"a" b`, "after string"},
+		{`"bad \q escape"`, "invalid string"},
+		{``, "missing value"},
+		{`# only a comment`, "missing value"},
+	}
+	for _, tt := range tests {
+		_, err := parseValue(tt.value)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseValue(%s): got %v, want an error mentioning %q", tt.value, err, tt.want)
+		}
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	text := `# text2babe configuration
+top = "level"
+
+[settings]
+output = "base64"   # how ciphertext is written
+encryption = true
+fec = 32
+
+[ recipes ]
+"my recipe" = "gzip | aes-gcm | base64"
+"a=b" = "hex"
+`
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	data, err := readFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fileData{
+		"":         {"top": "level"},
+		"settings": {"output": "base64", "encryption": "true", "fec": "32"},
+		"recipes":  {"my recipe": "gzip | aes-gcm | base64", "a=b": "hex"},
+	}
+	for section, values := range want {
+		for key, value := range values {
+			if got := data[section][key]; got != value {
+				t.Errorf("[%s] %s = %q, want %q", section, key, got, value)
+			}
+		}
+		if len(data[section]) != len(values) {
+			t.Errorf("[%s] has %v, want %v", section, data[section], values)
+		}
+	}
+}
+
+func TestReadFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	if data, err := readFile(filepath.Join(dir, "missing.toml")); err != nil || len(data) != 0 {
+		t.Errorf("missing file: got %v, %v", data, err)
+	}
+
+	tests := []struct {
+		text string
+		want string // part of the error message
+	}{
+		{"[settings]\noutput\n", ":2: expected key = value"},
+		{"output = \"hex\" trailing\n", ":1: unexpected"},
+		{"output = \"hex\n", ":1: unterminated string"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, "config.toml")
+		if err := os.WriteFile(path, []byte(tt.text), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := readFile(path)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: got %v, want an error mentioning %q", tt.text, err, tt.want)
+		}
+	}
+}
+
+// Whatever writeFile saves, readFile reads back the same
+func TestWriteFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "config.toml")
+	data := fileData{
+		"settings": {"output": "base64", "encryption": "true", "classic": `vigenere:"key" # not a comment`},
+		"recipes":  {"two words": "gzip | base64", "a=b": "hex", `quote"d`: "line\nbreak\ttab \\"},
+	}
+	if err := writeFile(path, data); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for section, values := range data {
+		for key, value := range values {
+			if got[section][key] != value {
+				t.Errorf("[%s] %q = %q, want %q", section, key, got[section][key], value)
+			}
+		}
+	}
+
+	text, _ := os.ReadFile(path)
+	if !strings.Contains(string(text), "encryption = true\n") {
+		t.Errorf("booleans should be written bare:\n%s", text)
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0o600 {
+		t.Errorf("config file mode %v, want 0600", info.Mode().Perm())
+	}
+}
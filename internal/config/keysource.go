@@ -0,0 +1,174 @@
+package config
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"doc0x1/text2babe/internal/paper"
+)
+
+// Key references name where a key comes from without holding the secret, so
+// they can be stored in the config file and profiles:
+//
+//	file:<path>     a key file (t2b-key: string or password on the first line)
+//	env:<VAR>       an environment variable
+//	command:<cmd>   the output of a command, e.g. a password manager
+//	default         the built-in default key
+
+// ErrDefaultKey is returned when encrypting would use the built-in default
+// key, which anyone with the source code can derive
+var ErrDefaultKey = errors.New("refusing to encrypt with the default key, which anyone with the source code can derive")
+
+// SetKeyRef loads the key from a reference, keeping the reference so the
+// config file can store it instead of the secret. A key command isn't run
+// here but by LoadKey, when the key is first needed.
+func (c *Config) SetKeyRef(ref string) error {
+	if ref == "default" {
+		c.SetKey("default-password")
+		return nil
+	}
+	kind, target, _ := strings.Cut(ref, ":")
+	switch kind {
+	case "file":
+		return c.LoadKeyFile(target)
+	case "env":
+		return c.LoadKeyEnv(target)
+	case "command":
+		if strings.TrimSpace(target) == "" {
+			return fmt.Errorf("key command is empty")
+		}
+		c.Key = nil
+		c.KeySource = ref
+		c.KeyRef = ref
+		c.keyCommand = target
+		return nil
+	}
+	return fmt.Errorf("key reference must be file:<path>, env:<VAR>, command:<command> or default")
+}
+
+// LoadKeyFile sets the key from a file holding either a t2b-key: string
+// (as written by 'key restore --out') or a password on its first line
+func (c *Config) LoadKeyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read key file: %w", err)
+	}
+	if err := c.setKeyText(string(data), "file:"+path); err != nil {
+		return fmt.Errorf("key file %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadKeyEnv sets the key from an environment variable
+func (c *Config) LoadKeyEnv(name string) error {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return fmt.Errorf("environment variable %s is not set", name)
+	}
+	if err := c.setKeyText(value, "env:"+name); err != nil {
+		return fmt.Errorf("environment variable %s: %w", name, err)
+	}
+	return nil
+}
+
+// KeyPending reports whether the key comes from a command that hasn't run yet
+func (c *Config) KeyPending() bool {
+	return c.keyCommand != ""
+}
+
+// LoadKey runs the key command set by SetKeyRef, if it hasn't run yet. Call
+// it before using Key; it does nothing for keys that are already loaded.
+func (c *Config) LoadKey() error {
+	if c.keyCommand == "" {
+		return nil
+	}
+	return c.LoadKeyCommand(c.keyCommand)
+}
+
+// LoadKeyCommand runs command once through the shell and sets the key from
+// its output. The command gets no stdin, so it can't swallow data piped to
+// text2babe; stderr stays on the terminal so password managers can prompt
+// there or on /dev/tty.
+func (c *Config) LoadKeyCommand(command string) error {
+	if strings.TrimSpace(command) == "" {
+		return fmt.Errorf("key command is empty")
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("key command %q failed: %w", command, err)
+	}
+	if err := c.setKeyText(out.String(), "command:"+command); err != nil {
+		return fmt.Errorf("key command %q: %w", command, err)
+	}
+	return nil
+}
+
+// setKeyText sets the key from a t2b-key: string or a password on the first
+// line of text, and records ref as where it came from
+func (c *Config) setKeyText(text, ref string) error {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, paper.KeyPrefix) {
+		key, err := paper.ParseKeyString(text)
+		if err != nil {
+			return err
+		}
+		c.SetRawKey(key, ref)
+	} else {
+		password, _, _ := strings.Cut(text, "\n")
+		password = strings.TrimRight(password, "\r")
+		if password == "" {
+			return fmt.Errorf("no key found")
+		}
+		c.SetKey(password)
+	}
+	c.KeyRef = ref
+	return nil
+}
+
+// CreateKeyFile generates a random key, writes it as a t2b-key: string to a
+// new key file next to the config file and uses it. It returns the path.
+func (c *Config) CreateKeyFile() (string, error) {
+	configPath, err := FilePath()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(filepath.Dir(configPath), "key")
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if os.IsExist(err) {
+		return "", fmt.Errorf("key file %s already exists", path)
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := fmt.Fprintln(f, paper.KeyString(key)); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return path, c.LoadKeyFile(path)
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"doc0x1/text2babe/internal/paper"
+)
+
+func TestLoadKeySources(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("key commands are run with sh")
+	}
+	dir := t.TempDir()
+	raw := bytes.Repeat([]byte{0x5a}, 32)
+	files := map[string]string{
+		"password":   "team password\nsecond line is ignored\n",
+		"crlf":       "team password\r\n",
+		"key string": paper.KeyString(raw) + "\n",
+		"empty":      "\n\n",
+	}
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("T2B_TEST_PASSWORD", "team password")
+	t.Setenv("T2B_TEST_KEY", paper.KeyString(raw))
+	t.Setenv("T2B_TEST_EMPTY", "")
+	password := generateKey("team password")
+
+	tests := []struct {
+		ref  string
+		want []byte
+	}{
+		{"file:" + filepath.Join(dir, "password"), password},
+		{"file:" + filepath.Join(dir, "crlf"), password},
+		{"file:" + filepath.Join(dir, "key string"), raw},
+		{"env:T2B_TEST_PASSWORD", password},
+		{"env:T2B_TEST_KEY", raw},
+		{"command:echo team password", password},
+		{"command:printf '%s' \"$T2B_TEST_KEY\"", raw},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			c := testConfig(t)
+			if err := c.SetKeyRef(tt.ref); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.LoadKey(); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(c.Key, tt.want) {
+				t.Errorf("got key %x, want %x", c.Key, tt.want)
+			}
+			if c.KeyRef != tt.ref || c.KeyPending() || c.IsDefaultKey() {
+				t.Errorf("KeyRef %q, pending %v, default %v", c.KeyRef, c.KeyPending(), c.IsDefaultKey())
+			}
+		})
+	}
+
+	errors := []struct {
+		ref  string
+		want string // part of the error message
+	}{
+		{"file:" + filepath.Join(dir, "missing"), "failed to read key file"},
+		{"file:" + filepath.Join(dir, "empty"), "no key found"},
+		{"env:T2B_TEST_UNSET", "is not set"},
+		{"env:T2B_TEST_EMPTY", "no key found"},
+		{"command:exit 3", "failed"},
+		{"command:true", "no key found"},
+		{"command: ", "key command is empty"},
+		{"vault:secret", "key reference must be"},
+	}
+	for _, tt := range errors {
+		t.Run(tt.ref, func(t *testing.T) {
+			c := testConfig(t)
+			err := c.SetKeyRef(tt.ref)
+			if err == nil {
+				err = c.LoadKey()
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want an error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// A key command runs when the key is first needed, and only once
+func TestKeyCommandRunsOnce(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("key commands are run with sh")
+	}
+	c := testConfig(t)
+	count := filepath.Join(t.TempDir(), "count")
+	if err := c.SetKeyRef("command:echo run >> " + count + "; echo team password"); err != nil {
+		t.Fatal(err)
+	}
+	if !c.KeyPending() || c.Key != nil {
+		t.Fatal("the command ran before the key was needed")
+	}
+	for i := 0; i < 3; i++ {
+		if err := c.LoadKey(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runs, _ := os.ReadFile(count)
+	if n := strings.Count(string(runs), "run"); n != 1 {
+		t.Errorf("command ran %d times", n)
+	}
+}
+
+// A key reference is saved; a key from the environment is left to it
+func TestSaveKeySetting(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "team.key")
+	if err := os.WriteFile(keyFile, []byte("team password\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TEXT2BABE_KEY_FILE", keyFile)
+	c := testConfig(t)
+	if c.SourceOf("key") != SourceEnv || c.KeyRef != "file:"+keyFile {
+		t.Fatalf("key %q from %s", c.KeyRef, c.SourceOf("key"))
+	}
+	if err := c.SaveSetting("key"); err != nil {
+		t.Fatal(err)
+	}
+	path, _ := FilePath()
+	if data, _ := readFile(path); data["settings"]["key"] != "" {
+		t.Errorf("saved the environment's key reference %q", data["settings"]["key"])
+	}
+
+	if err := c.Set("key", "env:T2B_TEST_PASSWORD", SourceFlag); err == nil {
+		t.Fatal("set a key from an unset variable")
+	}
+	t.Setenv("T2B_TEST_PASSWORD", "team password")
+	if err := c.Set("key", "env:T2B_TEST_PASSWORD", SourceFlag); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SaveSetting("key"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := readFile(path); data["settings"]["key"] != "env:T2B_TEST_PASSWORD" {
+		t.Errorf("saved key reference %q", data["settings"]["key"])
+	}
+}
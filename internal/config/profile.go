@@ -0,0 +1,172 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"doc0x1/text2babe/internal/discord"
+)
+
+// ProfileSettings are the settings a profile bundles, in the order they are
+// applied (the Discord channel before auto-send, which needs it)
+var ProfileSettings = []string{"output", "encryption", "cipher", "key", "discord-id", "discord"}
+
+const profilePrefix = "profile."
+
+// validProfileName reports whether name can be used as a profile name
+func validProfileName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, " \t.[]\"#=")
+}
+
+// Profile returns the stored settings of a profile
+func Profile(name string) (map[string]string, error) {
+	path, err := FilePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := data[profilePrefix+name]
+	if !ok {
+		return nil, fmt.Errorf("no profile named %s (see 'profile list')", name)
+	}
+	return values, nil
+}
+
+// ProfileNames returns the stored profile names in sorted order
+func ProfileNames() []string {
+	path, err := FilePath()
+	if err != nil {
+		return nil
+	}
+	data, err := readFile(path)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for section := range data {
+		if name, ok := strings.CutPrefix(section, profilePrefix); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CreateProfile stores the current profile settings under name, replacing
+// any profile of that name. A typed password has no reference and is not
+// stored; the default key is stored as "default".
+func (c *Config) CreateProfile(name string) (map[string]string, error) {
+	if !validProfileName(name) {
+		return nil, fmt.Errorf("invalid profile name %q", name)
+	}
+	path, err := FilePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("not overwriting unreadable config file: %w", err)
+	}
+
+	values := map[string]string{}
+	for _, setting := range ProfileSettings {
+		if value := c.Get(setting); value != "" {
+			values[setting] = value
+		}
+	}
+	if c.IsDefaultKey() {
+		values["key"] = "default"
+	}
+	data[profilePrefix+name] = values
+	if err := writeFile(path, data); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// DeleteProfile removes a stored profile, deactivating it if it was in use
+func (c *Config) DeleteProfile(name string) error {
+	path, err := FilePath()
+	if err != nil {
+		return err
+	}
+	data, err := readFile(path)
+	if err != nil {
+		return fmt.Errorf("not overwriting unreadable config file: %w", err)
+	}
+	if _, ok := data[profilePrefix+name]; !ok {
+		return fmt.Errorf("no profile named %s", name)
+	}
+	delete(data, profilePrefix+name)
+	if data["settings"]["profile"] == name {
+		delete(data["settings"], "profile")
+	}
+	if c.Profile == name {
+		c.Profile = ""
+	}
+	return writeFile(path, data)
+}
+
+// UseProfile applies a stored profile, recording src as where the choice of
+// profile came from. Settings that fail to apply, such as auto-send without
+// Discord configured, are skipped and returned as one error.
+func (c *Config) UseProfile(name string, src Source) error {
+	values, err := Profile(name)
+	if err != nil {
+		return err
+	}
+	c.Profile = name
+	c.MarkSet("profile", src)
+
+	var errs []error
+	for _, setting := range ProfileSettings {
+		value, ok := values[setting]
+		if !ok {
+			continue
+		}
+		err := c.Set(setting, value, SourceProfile)
+		if setting == "discord" && errors.Is(err, discord.ErrNotConfigured) {
+			// Auto-send stays on but idle, as it does by default
+			c.SendToDiscord = true
+			c.MarkSet(setting, SourceProfile)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", setting, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SaveActiveProfile records the active profile in the config file so later
+// runs start with it. Saved settings the profile covers are dropped, since
+// they would override it.
+func (c *Config) SaveActiveProfile() error {
+	path, err := FilePath()
+	if err != nil {
+		return err
+	}
+	data, err := readFile(path)
+	if err != nil {
+		return fmt.Errorf("not overwriting unreadable config file: %w", err)
+	}
+	settings := data["settings"]
+	if settings == nil {
+		settings = map[string]string{}
+		data["settings"] = settings
+	}
+	for _, setting := range ProfileSettings {
+		delete(settings, setting)
+	}
+	if c.Profile == "" {
+		delete(settings, "profile")
+	} else {
+		settings["profile"] = c.Profile
+	}
+	return writeFile(path, data)
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	t.Setenv("DISCORD_DM_ID", "")
+	c := testConfig(t)
+	keyFile := filepath.Join(t.TempDir(), "team.key")
+	if err := os.WriteFile(keyFile, []byte("team password\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, setting := range [][2]string{{"output", "base64"}, {"encryption", "on"}, {"cipher", "chacha"}, {"key", "file:" + keyFile}} {
+		if err := c.Set(setting[0], setting[1], SourceShell); err != nil {
+			t.Fatal(err)
+		}
+	}
+	work, err := c.CreateProfile("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if work["key"] != "file:"+keyFile || work["cipher"] != "chacha20-poly1305" || work["encryption"] != "true" {
+		t.Errorf("work profile is %v", work)
+	}
+
+	c.Set("output", "binary", SourceShell)
+	c.Set("encryption", "off", SourceShell)
+	c.SetKey("typed password")
+	ctf, err := c.CreateProfile("ctf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ctf["key"]; ok {
+		t.Errorf("a typed password was stored as %q", ctf["key"])
+	}
+	c.SetKey("default-password")
+	if defaults, _ := c.CreateProfile("defaults"); defaults["key"] != "default" {
+		t.Errorf("default key stored as %q", defaults["key"])
+	}
+
+	if got := ProfileNames(); !slices.Equal(got, []string{"ctf", "defaults", "work"}) {
+		t.Errorf("ProfileNames = %v", got)
+	}
+
+	// A fresh start picks the profile up from the settings
+	fresh := New()
+	if err := fresh.UseProfile("work", SourceShell); err != nil {
+		t.Fatal(err)
+	}
+	if fresh.OutputMode != "base64" || !fresh.UseEncryption || fresh.Cipher != "chacha20-poly1305" || fresh.KeyRef != "file:"+keyFile {
+		t.Errorf("work profile gave output %s, encryption %v, cipher %s, key %s", fresh.OutputMode, fresh.UseEncryption, fresh.Cipher, fresh.KeyRef)
+	}
+	if fresh.SourceOf("output") != SourceProfile || fresh.SourceOf("profile") != SourceShell {
+		t.Errorf("sources: output %s, profile %s", fresh.SourceOf("output"), fresh.SourceOf("profile"))
+	}
+	if err := fresh.UseProfile("ctf", SourceShell); err != nil {
+		t.Fatal(err)
+	}
+	if fresh.OutputMode != "binary" || fresh.UseEncryption || fresh.Profile != "ctf" {
+		t.Errorf("ctf profile gave output %s, encryption %v", fresh.OutputMode, fresh.UseEncryption)
+	}
+}
+
+// Saving the active profile drops the settings it covers, and settings
+// saved afterwards override it
+func TestSaveActiveProfile(t *testing.T) {
+	t.Setenv("DISCORD_DM_ID", "")
+	c := testConfig(t)
+	c.Set("output", "armor", SourceShell)
+	if _, err := c.CreateProfile("mail"); err != nil {
+		t.Fatal(err)
+	}
+	c.Set("output", "qp", SourceShell)
+	c.SaveSettings()
+
+	c.Set("profile", "mail", SourceShell)
+	if err := c.SaveActiveProfile(); err != nil {
+		t.Fatal(err)
+	}
+	if got := New(); got.Profile != "mail" || got.OutputMode != "armor" {
+		t.Errorf("started with profile %q and output %s", got.Profile, got.OutputMode)
+	}
+
+	// An output saved after choosing the profile wins over it
+	path, _ := FilePath()
+	data, _ := readFile(path)
+	data["settings"]["output"] = "hex"
+	writeFile(path, data)
+	if got := New(); got.OutputMode != "hex" || got.SourceOf("output") != SourceFile {
+		t.Errorf("output %s from %s, want hex from the config file", got.OutputMode, got.SourceOf("output"))
+	}
+
+	if err := c.DeleteProfile("mail"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Profile != "" {
+		t.Errorf("deleted profile is still active")
+	}
+	if got := New(); got.Profile != "" || len(got.Warnings) > 0 {
+		t.Errorf("after delete: profile %q, warnings %v", got.Profile, got.Warnings)
+	}
+}
+
+func TestProfileErrors(t *testing.T) {
+	c := testConfig(t)
+	for _, name := range []string{"", "two words", "a.b", "[x]", `q"`} {
+		if _, err := c.CreateProfile(name); err == nil || !strings.Contains(err.Error(), "invalid profile name") {
+			t.Errorf("CreateProfile(%q): got %v", name, err)
+		}
+	}
+	if err := c.UseProfile("missing", SourceShell); err == nil || !strings.Contains(err.Error(), "no profile named missing") {
+		t.Errorf("UseProfile: got %v", err)
+	}
+	if err := c.DeleteProfile("missing"); err == nil {
+		t.Error("deleted a missing profile")
+	}
+	if err := c.Set("profile", "missing", SourceShell); err == nil {
+		t.Error("set a missing profile")
+	}
+}
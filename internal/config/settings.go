@@ -0,0 +1,319 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"doc0x1/text2babe/internal/discord"
+)
+
+// Source says where a setting's current value came from. Later sources
+// override earlier ones: defaults, the active profile, the config file, the
+// environment, then command-line flags. Changes made in the shell are the
+// most recent.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "config file"
+	SourceProfile Source = "profile"
+	SourceEnv     Source = "environment"
+	SourceFlag    Source = "flag"
+	SourceShell   Source = "shell"
+)
+
+// Persisted lists the settings kept in the [settings] section of the config
+// file, in the order they are applied. The profile comes first so the other
+// saved settings can override it. The key is only stored as a reference to
+// where it comes from, never as the password or key itself.
+var Persisted = []string{
+	"profile", "output", "input", "datatype", "encryption", "cipher", "fec", "classic",
+	"recipe", "qr", "clipboard", "discord", "discord-id", "key",
+}
+
+// bareSettings are written to the config file without quotes
+var bareSettings = map[string]bool{
+	"encryption": true, "qr": true, "clipboard": true, "discord": true, "fec": true,
+}
+
+// settingAliases maps alternative names accepted by Set to their canonical name
+var settingAliases = map[string]string{
+	"type":         "datatype",
+	"format":       "output",
+	"input-format": "input",
+	"dmid":         "discord-id",
+}
+
+// SettingName returns the canonical name of a setting
+func SettingName(name string) string {
+	name = strings.ToLower(name)
+	if canonical, ok := settingAliases[name]; ok {
+		return canonical
+	}
+	return name
+}
+
+// parseSwitch reads an on/off setting value
+func parseSwitch(name, value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "on", "enable", "yes", "1":
+		return true, nil
+	case "false", "off", "disable", "no", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("%s must be 'true/on/enable' or 'false/off/disable'", name)
+}
+
+// Set validates and applies one setting, recording where the value came from
+func (c *Config) Set(name, value string, src Source) error {
+	name = SettingName(name)
+	switch name {
+	case "mode":
+		if !c.SetMode(value) {
+			return fmt.Errorf("mode must be 'encrypt' or 'decrypt'")
+		}
+	case "datatype":
+		if !c.SetDataType(value) {
+			return fmt.Errorf("data type must be 'text' or 'binary'")
+		}
+	case "output":
+		if !c.SetOutputMode(value) {
+			return fmt.Errorf("output mode must be one of hex, base64, binary, armor, hexdump, url, html, unicode, qp")
+		}
+	case "input":
+		if !c.SetInputFormat(value) {
+			return fmt.Errorf("input format must be one of auto, armor, hexdump, hex, base64, binary, url, html, unicode, qp, text")
+		}
+	case "recipe":
+		switch value {
+		case "false", "off", "none", "disable":
+			c.Recipe = ""
+		default:
+			if _, ok := c.Recipes[value]; !ok {
+				return fmt.Errorf("no recipe named %s (see 'recipe list')", value)
+			}
+			c.Recipe = value
+		}
+	case "discord":
+		on, err := parseSwitch(name, value)
+		if err != nil {
+			return err
+		}
+		if !c.SetDiscordSending(on) {
+			return discord.ErrNotConfigured
+		}
+	case "encryption":
+		on, err := parseSwitch(name, value)
+		if err != nil {
+			return err
+		}
+		c.SetEncryption(on)
+	case "cipher":
+		if !c.SetCipher(value) {
+			return fmt.Errorf("cipher must be 'aes-gcm' or 'chacha20-poly1305'")
+		}
+	case "clipboard":
+		on, err := parseSwitch(name, value)
+		if err != nil {
+			return err
+		}
+		c.Clipboard = on
+	case "qr":
+		on, err := parseSwitch(name, value)
+		if err != nil {
+			return err
+		}
+		c.ShowQR = on
+	case "stego":
+		switch value {
+		case "false", "off", "disable":
+			c.StegoCover = ""
+		default:
+			c.StegoCover = value
+		}
+	case "classic":
+		if err := c.SetClassic(value); err != nil {
+			return err
+		}
+	case "fec":
+		if err := c.SetFEC(value); err != nil {
+			return err
+		}
+	case "discord-id":
+		if !c.GetDiscord().SetDMID(value) {
+			return fmt.Errorf("invalid Discord DM ID (cannot be empty)")
+		}
+	case "key":
+		if err := c.SetKeyRef(value); err != nil {
+			return err
+		}
+	case "profile":
+		switch value {
+		case "", "off", "none":
+			c.Profile = ""
+		default:
+			// UseProfile records the source itself, even if some settings fail
+			return c.UseProfile(value, src)
+		}
+	default:
+		return fmt.Errorf("unknown setting: %s", name)
+	}
+	c.MarkSet(name, src)
+	return nil
+}
+
+// MarkSet records that a setting was changed outside Set, e.g. by a toggle
+func (c *Config) MarkSet(name string, src Source) {
+	c.Sources[SettingName(name)] = src
+}
+
+// Get returns a persisted setting's value in the form Set accepts
+func (c *Config) Get(name string) string {
+	switch SettingName(name) {
+	case "output":
+		return c.OutputMode
+	case "input":
+		return c.InputFormat
+	case "datatype":
+		return c.DataType
+	case "encryption":
+		return strconv.FormatBool(c.UseEncryption)
+	case "cipher":
+		return c.Cipher
+	case "fec":
+		return strconv.Itoa(c.FEC)
+	case "classic":
+		return c.Classic
+	case "recipe":
+		return c.Recipe
+	case "qr":
+		return strconv.FormatBool(c.ShowQR)
+	case "clipboard":
+		return strconv.FormatBool(c.Clipboard)
+	case "discord":
+		return strconv.FormatBool(c.SendToDiscord)
+	case "discord-id":
+		return c.GetDiscord().GetDMID()
+	case "key":
+		return c.KeyRef
+	case "profile":
+		return c.Profile
+	}
+	return ""
+}
+
+// SourceOf returns where a setting's current value came from
+func (c *Config) SourceOf(name string) Source {
+	if src, ok := c.Sources[SettingName(name)]; ok {
+		return src
+	}
+	return SourceDefault
+}
+
+// loadSettings applies the [settings] section of the config file. Invalid
+// entries are skipped and reported in Warnings.
+func (c *Config) loadSettings(data fileData) {
+	values := data["settings"]
+	for _, name := range Persisted {
+		value, ok := values[name]
+		if !ok {
+			continue
+		}
+		if err := c.Set(name, value, SourceFile); err != nil {
+			c.Warnings = append(c.Warnings, fmt.Sprintf("config file: %s: %v", name, err))
+		}
+	}
+	for name := range values {
+		if !isPersisted(name) {
+			c.Warnings = append(c.Warnings, fmt.Sprintf("config file: unknown setting %s", name))
+		}
+	}
+}
+
+func isPersisted(name string) bool {
+	for _, p := range Persisted {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveSettings writes the settings that differ from the defaults to the
+// [settings] section, leaving other sections untouched. A key typed as a
+// password has no reference and is not saved, and environment overrides
+// are not saved either.
+func (c *Config) SaveSettings() error {
+	path, err := FilePath()
+	if err != nil {
+		return err
+	}
+	data, err := readFile(path)
+	if err != nil {
+		return fmt.Errorf("not overwriting unreadable config file: %w", err)
+	}
+
+	// Settings that match what the active profile gives are left to it
+	baseline := newDefaults()
+	baseline.Recipes = c.Recipes
+	if c.Profile != "" {
+		baseline.UseProfile(c.Profile, SourceFile)
+		baseline.Profile = ""
+	}
+	settings := map[string]string{}
+	for _, name := range Persisted {
+		if c.SourceOf(name) == SourceEnv {
+			// Environment overrides belong to the environment; keep the file's value
+			if value, ok := data["settings"][name]; ok {
+				settings[name] = value
+			}
+			continue
+		}
+		if value := c.Get(name); value != baseline.Get(name) {
+			settings[name] = value
+		}
+	}
+	data["settings"] = settings
+	return writeFile(path, data)
+}
+
+// SaveSetting writes one setting's current value to the [settings] section,
+// leaving the rest of the file untouched. A value from the environment
+// belongs to the environment and is not written, as with SaveSettings.
+func (c *Config) SaveSetting(name string) error {
+	name = SettingName(name)
+	if c.SourceOf(name) == SourceEnv {
+		return nil
+	}
+	path, err := FilePath()
+	if err != nil {
+		return err
+	}
+	data, err := readFile(path)
+	if err != nil {
+		return fmt.Errorf("not overwriting unreadable config file: %w", err)
+	}
+	if data["settings"] == nil {
+		data["settings"] = map[string]string{}
+	}
+	data["settings"][name] = c.Get(name)
+	return writeFile(path, data)
+}
+
+// ResetSettings removes the [settings] section from the config file
+func ResetSettings() error {
+	path, err := FilePath()
+	if err != nil {
+		return err
+	}
+	data, err := readFile(path)
+	if err != nil {
+		return fmt.Errorf("not overwriting unreadable config file: %w", err)
+	}
+	if _, ok := data["settings"]; !ok {
+		return nil
+	}
+	delete(data, "settings")
+	return writeFile(path, data)
+}
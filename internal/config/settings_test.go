@@ -0,0 +1,132 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes text as the config file of a fresh config directory
+func writeConfig(t *testing.T, text string) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path, err := FilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Flags override the config file, which overrides the defaults
+func TestFilePrecedence(t *testing.T) {
+	writeConfig(t, `[settings]
+output = "base64"
+cipher = "chacha20-poly1305"
+qr = true
+`)
+	c := New()
+	if len(c.Warnings) > 0 {
+		t.Fatalf("warnings: %v", c.Warnings)
+	}
+	tests := []struct {
+		name  string
+		value string
+		src   Source
+	}{
+		{"output", "base64", SourceFile},
+		{"cipher", "chacha20-poly1305", SourceFile},
+		{"qr", "true", SourceFile},
+		{"input", "auto", SourceDefault},
+	}
+	for _, tt := range tests {
+		if got := c.Get(tt.name); got != tt.value {
+			t.Errorf("%s = %q, want %q", tt.name, got, tt.value)
+		}
+		if got := c.SourceOf(tt.name); got != tt.src {
+			t.Errorf("%s comes from %s, want %s", tt.name, got, tt.src)
+		}
+	}
+
+	if err := c.Set("output", "armor", SourceFlag); err != nil {
+		t.Fatal(err)
+	}
+	if c.OutputMode != "armor" || c.SourceOf("format") != SourceFlag {
+		t.Errorf("flag gave output %s from %s", c.OutputMode, c.SourceOf("output"))
+	}
+}
+
+func TestFileWarnings(t *testing.T) {
+	writeConfig(t, `[settings]
+output = "morse"
+colour = "blue"
+cipher = "aes"
+`)
+	c := New()
+	if c.Cipher != "aes-gcm" || c.OutputMode != "hex" {
+		t.Errorf("got cipher %s, output %s", c.Cipher, c.OutputMode)
+	}
+	warnings := strings.Join(c.Warnings, "\n")
+	for _, want := range []string{"output: output mode must be", "unknown setting colour"} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("warnings %q do not mention %q", warnings, want)
+		}
+	}
+
+	writeConfig(t, "output = \"unterminated\n")
+	if c := New(); len(c.Warnings) != 1 || !strings.Contains(c.Warnings[0], "config file ignored") {
+		t.Errorf("unreadable file gave warnings %v", c.Warnings)
+	}
+}
+
+func TestSaveSettings(t *testing.T) {
+	writeConfig(t, `[recipes]
+backup = "gzip | aes-gcm | base64"
+`)
+	c := New()
+	c.Set("output", "armor", SourceShell)
+	c.Set("encryption", "on", SourceShell)
+	c.Set("input", "auto", SourceShell) // the default, so not written
+	c.SetKey("typed password")
+	if err := c.SaveSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	path, _ := FilePath()
+	data, err := readFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"output": "armor", "encryption": "true"}
+	if len(data["settings"]) != len(want) {
+		t.Errorf("saved %v, want %v", data["settings"], want)
+	}
+	for name, value := range want {
+		if data["settings"][name] != value {
+			t.Errorf("saved %s = %q, want %q", name, data["settings"][name], value)
+		}
+	}
+	if data["recipes"]["backup"] == "" {
+		t.Error("saving settings dropped the recipes")
+	}
+	text, _ := os.ReadFile(path)
+	if strings.Contains(string(text), "typed password") {
+		t.Error("the password was written to the config file")
+	}
+
+	// The saved settings are loaded again, and reset removes them
+	if c := New(); c.OutputMode != "armor" || !c.UseEncryption {
+		t.Errorf("reloaded output %s, encryption %v", c.OutputMode, c.UseEncryption)
+	}
+	if err := ResetSettings(); err != nil {
+		t.Fatal(err)
+	}
+	if c := New(); c.OutputMode != "hex" || c.Recipes["backup"] == "" {
+		t.Errorf("after reset: output %s, recipes %v", c.OutputMode, c.Recipes)
+	}
+}
@@ -0,0 +1,134 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// ASCII armor: a PEM-like block with optional headers, base64 body wrapped
+// at 64 columns and an OpenPGP-style CRC24 checksum line
+
+const (
+	armorBegin = "-----BEGIN TEXT2BABE MESSAGE-----"
+	armorEnd   = "-----END TEXT2BABE MESSAGE-----"
+	armorWidth = 64
+)
+
+// ArmorHeader is a "Name: value" line in an armored block
+type ArmorHeader struct {
+	Name  string
+	Value string
+}
+
+// Armor wraps data in a TEXT2BABE MESSAGE block
+func Armor(data []byte, headers []ArmorHeader) string {
+	var b strings.Builder
+	b.WriteString(armorBegin + "\n")
+	for _, h := range headers {
+		fmt.Fprintf(&b, "%s: %s\n", h.Name, h.Value)
+	}
+	if len(headers) > 0 {
+		b.WriteString("\n")
+	}
+
+	body := base64.StdEncoding.EncodeToString(data)
+	for len(body) > armorWidth {
+		b.WriteString(body[:armorWidth] + "\n")
+		body = body[armorWidth:]
+	}
+	if body != "" {
+		b.WriteString(body + "\n")
+	}
+
+	crc := crc24(data)
+	b.WriteString("=" + base64.StdEncoding.EncodeToString([]byte{byte(crc >> 16), byte(crc >> 8), byte(crc)}) + "\n")
+	b.WriteString(armorEnd)
+	return b.String()
+}
+
+// ContainsArmor reports whether text contains an armored block
+func ContainsArmor(text string) bool {
+	return strings.Contains(text, armorBegin)
+}
+
+// Dearmor finds the first armored block anywhere in text (surrounding chat
+// lines, email quoting with "> " prefixes, or a block collapsed onto one
+// line are all accepted), verifies its checksum and returns the payload
+func Dearmor(text string) ([]byte, []ArmorHeader, error) {
+	start := strings.Index(text, armorBegin)
+	if start < 0 {
+		return nil, nil, fmt.Errorf("no TEXT2BABE MESSAGE block found")
+	}
+	rest := text[start+len(armorBegin):]
+	end := strings.Index(rest, armorEnd)
+	if end < 0 {
+		return nil, nil, fmt.Errorf("armored block has no END line")
+	}
+
+	// Strip quote markers from each line, then work on whitespace-separated tokens
+	var tokens []string
+	for _, line := range strings.Split(rest[:end], "\n") {
+		line = strings.TrimLeft(strings.TrimSpace(line), "> \t")
+		tokens = append(tokens, strings.Fields(line)...)
+	}
+
+	var headers []ArmorHeader
+	var body strings.Builder
+	checksum := ""
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case strings.HasSuffix(tok, ":") && i+1 < len(tokens):
+			headers = append(headers, ArmorHeader{Name: strings.TrimSuffix(tok, ":"), Value: tokens[i+1]})
+			i++
+		case strings.HasPrefix(tok, "=") && len(tok) == 5:
+			checksum = tok[1:]
+		default:
+			body.WriteString(tok)
+		}
+	}
+
+	data, err := base64.StdEncoding.DecodeString(body.String())
+	if err != nil {
+		return nil, headers, fmt.Errorf("armored body is not valid base64 (transcription error?): %w", err)
+	}
+
+	if checksum == "" {
+		return nil, headers, fmt.Errorf("armored block has no checksum line")
+	}
+	sum, err := base64.StdEncoding.DecodeString(checksum)
+	if err != nil || len(sum) != 3 {
+		return nil, headers, fmt.Errorf("armor checksum line is malformed")
+	}
+	if want := uint32(sum[0])<<16 | uint32(sum[1])<<8 | uint32(sum[2]); crc24(data) != want {
+		return nil, headers, fmt.Errorf("armor checksum mismatch - the message was altered or mistyped")
+	}
+
+	return data, headers, nil
+}
+
+// ArmorHeaderValue returns the value of the named header, if present
+func ArmorHeaderValue(headers []ArmorHeader, name string) string {
+	for _, h := range headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// crc24 is the OpenPGP armor checksum (RFC 4880 section 6.1)
+func crc24(data []byte) uint32 {
+	crc := uint32(0xb704ce)
+	for _, b := range data {
+		crc ^= uint32(b) << 16
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= 0x1864cfb
+			}
+		}
+	}
+	return crc & 0xffffff
+}
@@ -0,0 +1,125 @@
+package crypto
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestCRC24(t *testing.T) {
+	// Check value of CRC-24/OPENPGP, and the initial value for no data
+	tests := []struct {
+		data string
+		want uint32
+	}{
+		{"", 0xb704ce},
+		{"123456789", 0x21cf02},
+	}
+	for _, tt := range tests {
+		if got := crc24([]byte(tt.data)); got != tt.want {
+			t.Errorf("crc24(%q) = %06x, want %06x", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestArmorRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	headers := []ArmorHeader{{"Version", "text2babe"}, {"Cipher", "aes-gcm"}}
+	for _, size := range []int{1, 2, 3, 47, 48, 49, 500} {
+		data := make([]byte, size)
+		rng.Read(data)
+		for _, h := range [][]ArmorHeader{nil, headers} {
+			armored := Armor(data, h)
+			for _, line := range strings.Split(armored, "\n") {
+				if len(line) > armorWidth && line != armorBegin && line != armorEnd {
+					t.Errorf("%d bytes: line longer than %d columns: %q", size, armorWidth, line)
+				}
+			}
+
+			got, gotHeaders, err := Dearmor(armored)
+			if err != nil {
+				t.Fatalf("%d bytes, %d headers: %v", size, len(h), err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("%d bytes, %d headers: payload differs", size, len(h))
+			}
+			if len(gotHeaders) != len(h) {
+				t.Fatalf("%d bytes: got headers %v, want %v", size, gotHeaders, h)
+			}
+			for i := range h {
+				if gotHeaders[i] != h[i] {
+					t.Errorf("%d bytes: header %d is %v, want %v", size, i, gotHeaders[i], h[i])
+				}
+			}
+		}
+	}
+}
+
+// Armored blocks survive the ways chat and email mangle them
+func TestDearmorMangled(t *testing.T) {
+	data := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog. ", 4))
+	armored := Armor(data, []ArmorHeader{{"Version", "text2babe"}})
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"surrounding chat", "hey, here it is:\n\n" + armored + "\n\nlet me know"},
+		{"email quoting", "> " + strings.ReplaceAll(armored, "\n", "\n> ")},
+		{"nested quoting", ">> " + strings.ReplaceAll(armored, "\n", "\n>> ")},
+		{"collapsed onto one line", strings.ReplaceAll(armored, "\n", " ")},
+		{"CRLF line endings", strings.ReplaceAll(armored, "\n", "\r\n")},
+		{"indented", "    " + strings.ReplaceAll(armored, "\n", "\n    ")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, headers, err := Dearmor(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("got %q, want %q", got, data)
+			}
+			if v := ArmorHeaderValue(headers, "version"); v != "text2babe" {
+				t.Errorf("Version header is %q", v)
+			}
+		})
+	}
+}
+
+func TestDearmorRejects(t *testing.T) {
+	armored := Armor([]byte("meet me by the old oak tree"), nil)
+	lines := strings.Split(armored, "\n")
+
+	// Change one body character to another valid base64 character
+	typo := []byte(armored)
+	i := len(armorBegin) + 5
+	if typo[i] == 'A' {
+		typo[i] = 'B'
+	} else {
+		typo[i] = 'A'
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want string // part of the error message
+	}{
+		{"no block", "just some text", "no TEXT2BABE MESSAGE block"},
+		{"no END line", strings.Join(lines[:len(lines)-1], "\n"), "no END line"},
+		{"no checksum", strings.Join(append(lines[:len(lines)-2:len(lines)-2], armorEnd), "\n"), "no checksum"},
+		{"typo in body", string(typo), "checksum mismatch"},
+		{"invalid base64", strings.Replace(armored, lines[1], lines[1]+"!", 1), "not valid base64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := Dearmor(tt.text)
+			if err == nil {
+				t.Fatalf("decoded %q from bad input", got)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not mention %q", err, tt.want)
+			}
+		})
+	}
+}
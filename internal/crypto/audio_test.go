@@ -0,0 +1,68 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+
+	"doc0x1/text2babe/internal/audio"
+	"doc0x1/text2babe/internal/config"
+)
+
+// testConfig returns the default settings, unaffected by the user's config
+// file, with the given password
+func testConfig(t *testing.T, password string) *config.Config {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	cfg := config.New()
+	cfg.SetKey(password)
+	return cfg
+}
+
+// Encrypted output sent through the audio modem decrypts to the original,
+// as with 'encrypt --audio' and 'decrypt --audio'
+func TestAudioRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		encryption bool
+		fec        string
+	}{
+		{"hex", "hex", true, "off"},
+		{"base64", "base64", true, "off"},
+		{"armor", "armor", true, "off"},
+		{"binary with FEC", "binary", true, "low"},
+		{"plain base64", "base64", false, "off"},
+	}
+	plaintext := "the drop is behind the third bench"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t, "correct horse battery staple")
+			cfg.SetEncryption(tt.encryption)
+			if !cfg.SetOutputMode(tt.output) {
+				t.Fatalf("output mode %s rejected", tt.output)
+			}
+			if err := cfg.SetFEC(tt.fec); err != nil {
+				t.Fatal(err)
+			}
+
+			encrypted, err := EncryptData(plaintext, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			received, err := audio.Decode(bytes.NewReader(audio.Encode([]byte(encrypted))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(received) != encrypted {
+				t.Fatalf("modem changed the message: got %q, want %q", received, encrypted)
+			}
+			result, err := Decrypt(string(received), cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(result.Plaintext) != plaintext {
+				t.Errorf("got %q, want %q", result.Plaintext, plaintext)
+			}
+		})
+	}
+}
@@ -8,47 +8,142 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"os"
 	"strconv"
 	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/chacha20poly1305"
 
 	"doc0x1/text2babe/internal/config"
+	"doc0x1/text2babe/internal/stego"
 )
 
 func EncryptData(data string, cfg *config.Config) (string, error) {
-	// Always treat input as text
-	inputBytes := []byte(data)
-	
-	var outputBytes []byte
+	outputBytes, err := EncryptBytes(data, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	// Textual classical ciphers are already readable and skip output encoding
+	c := cfg.ClassicCipher()
+	textual := c != nil && c.Textual()
+	if cfg.FEC > 0 && !textual {
+		outputBytes = AddFEC(outputBytes, cfg.FEC)
+	}
+
+	if cfg.StegoCover != "" {
+		return stego.HideText(cfg.StegoCover, outputBytes), nil
+	}
+
+	if textual {
+		return string(outputBytes), nil
+	}
+
+	if cfg.OutputMode == "armor" {
+		return Armor(outputBytes, armorHeaders(cfg)), nil
+	}
+
+	return EncodeOutput(outputBytes, cfg.OutputMode)
+}
+
+// armorHeaders describes the encryption layer for armored output
+func armorHeaders(cfg *config.Config) []ArmorHeader {
+	if !cfg.UseEncryption {
+		return nil
+	}
+	return []ArmorHeader{
+		{Name: "Cipher", Value: config.Ciphers[cfg.Cipher]},
+		{Name: "Key-ID", Value: cfg.KeyID()},
+	}
+}
+
+// EncryptBytes returns the raw encrypted (or plain) bytes before any output formatting
+func EncryptBytes(data string, cfg *config.Config) ([]byte, error) {
+	inputBytes, err := ReadInput(data, cfg)
+	if err != nil {
+		return nil, err
+	}
 	
-	if cfg.UseEncryption {
-		// AES-GCM encryption
-		block, err := aes.NewCipher(cfg.Key)
+	if !cfg.UseEncryption {
+		// Plain encoding - optionally through a classical cipher
+		if c := cfg.ClassicCipher(); c != nil {
+			return c.Encode(inputBytes)
+		}
+		return inputBytes, nil
+	}
+
+	// Authenticated encryption with the configured cipher
+	if err := cfg.LoadKey(); err != nil {
+		return nil, err
+	}
+	return SealWith(cfg.Cipher, cfg.Key, inputBytes)
+}
+
+// newAEAD creates the named authenticated cipher
+func newAEAD(name string, key []byte) (cipher.AEAD, error) {
+	switch name {
+	case "", "aes-gcm":
+		block, err := aes.NewCipher(key)
 		if err != nil {
-			return "", fmt.Errorf("failed to create cipher: %w", err)
+			return nil, fmt.Errorf("failed to create cipher: %w", err)
 		}
 		
 		gcm, err := cipher.NewGCM(block)
 		if err != nil {
-			return "", fmt.Errorf("failed to create GCM: %w", err)
+			return nil, fmt.Errorf("failed to create GCM: %w", err)
 		}
-		
-		nonce := make([]byte, gcm.NonceSize())
-		if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
-			return "", fmt.Errorf("failed to generate nonce: %w", err)
+		return gcm, nil
+	case "chacha20-poly1305":
+		aead, err := chacha20poly1305.New(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher: %w", err)
 		}
-		
-		outputBytes = gcm.Seal(nonce, nonce, inputBytes, nil)
-	} else {
-		// Plain encoding - just use the input bytes directly
-		outputBytes = inputBytes
+		return aead, nil
+	default:
+		return nil, fmt.Errorf("unknown cipher: %s", name)
+	}
+}
+
+// Seal encrypts plaintext with AES-GCM, prefixing the random nonce
+func Seal(key, plaintext []byte) ([]byte, error) {
+	return SealWith("aes-gcm", key, plaintext)
+}
+
+// SealWith encrypts plaintext with the named cipher, prefixing the random nonce
+func SealWith(name string, key, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(name, key)
+	if err != nil {
+		return nil, err
+	}
+	
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
 	}
 	
-	// Apply output format
-	switch cfg.OutputMode {
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// EncodeOutput renders bytes in the given output format
+func EncodeOutput(outputBytes []byte, outputMode string) (string, error) {
+	switch outputMode {
 	case "hex":
 		return hex.EncodeToString(outputBytes), nil
 	case "base64":
 		return base64.StdEncoding.EncodeToString(outputBytes), nil
+	case "armor":
+		return Armor(outputBytes, nil), nil
+	case "hexdump":
+		return Hexdump(outputBytes), nil
+	case "url":
+		return EncodeURL(outputBytes), nil
+	case "html":
+		return EncodeHTML(outputBytes), nil
+	case "unicode":
+		return EncodeUnicode(outputBytes), nil
+	case "qp":
+		return EncodeQuotedPrintable(outputBytes), nil
 	case "binary":
 		// For binary output, we need to ensure it's displayable
 		// Convert to a readable binary representation (0s and 1s)
@@ -62,74 +157,173 @@ func EncryptData(data string, cfg *config.Config) (string, error) {
 	}
 }
 
+// ReadInput returns the bytes to encrypt. Text mode uses the input as typed;
+// binary mode reads a file path, or decodes hex or base64.
+func ReadInput(data string, cfg *config.Config) ([]byte, error) {
+	if cfg.DataType != "binary" {
+		return []byte(data), nil
+	}
+
+	path := strings.TrimSpace(data)
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return os.ReadFile(path)
+	}
+	for _, format := range []string{"hex", "base64"} {
+		if decoded, err := DecodeFormat(data, format); err == nil && len(decoded) > 0 {
+			return decoded, nil
+		}
+	}
+	return nil, fmt.Errorf("binary mode expects a file path, hex or base64 input")
+}
+
+// Decrypted is recovered plaintext along with any repairs made to get it
+type Decrypted struct {
+	Plaintext []byte
+	Format    string // input format the data was read as
+	Corrected int    // bytes repaired by forward error correction
+}
+
+// DecryptData decrypts data for display; plaintext that isn't valid UTF-8
+// is returned as a hexdump
 func DecryptData(data string, cfg *config.Config) (string, error) {
-	var inputBytes []byte
-	var err error
-	
-	// Auto-detect input format with smart binary detection
-	// Check if data looks like binary (only 0s and 1s, length divisible by 8)
-	isBinaryFormat := len(data) > 8 && len(data)%8 == 0
-	for _, r := range data {
-		if r != '0' && r != '1' {
-			isBinaryFormat = false
-			break
+	result, err := Decrypt(data, cfg)
+	if err != nil {
+		return "", err
+	}
+	return displayText(result.Plaintext), nil
+}
+
+// Decrypt decrypts (or decodes) data and returns the raw plaintext. Input
+// framed with forward error correction is repaired before authentication.
+func Decrypt(data string, cfg *config.Config) (*Decrypted, error) {
+	if cfg.UseEncryption {
+		if err := cfg.LoadKey(); err != nil {
+			return nil, err
 		}
 	}
-	
-	if isBinaryFormat {
-		// If it looks like binary format, try binary parsing first
-		inputBytes, err = parseBinaryString(data)
+
+	// Zero-width payloads hidden in cover text skip format detection
+	if hidden, ok := stego.ExtractText(data); ok {
+		return openBytes(hidden, "stego", cfg)
+	}
+
+	// Armored blocks carry a checksum and may name the key they were sealed with
+	if ContainsArmor(data) {
+		payload, headers, err := Dearmor(data)
 		if err != nil {
-			// If binary parsing fails, fall back to other formats
-			inputBytes, err = hex.DecodeString(data)
-			if err != nil {
-				inputBytes, err = base64.StdEncoding.DecodeString(data)
-				if err != nil {
-					inputBytes = []byte(data)
-				}
-			}
+			return nil, err
 		}
-	} else {
-		// For non-binary data, try hex first, then base64, then raw binary
-		inputBytes, err = hex.DecodeString(data)
+		if keyID := ArmorHeaderValue(headers, "Key-ID"); cfg.UseEncryption && keyID != "" && keyID != cfg.KeyID() {
+			return nil, fmt.Errorf("%w: message was encrypted with key %s but the current key is %s", ErrAuthenticationFailed, keyID, cfg.KeyID())
+		}
+		// The Cipher header overrides the configured cipher
+		if name := config.CipherByName(ArmorHeaderValue(headers, "Cipher")); name != "" && name != cfg.Cipher {
+			armored := *cfg
+			armored.Cipher = name
+			return openBytes(payload, "armor", &armored)
+		}
+		return openBytes(payload, "armor", cfg)
+	}
+
+	// Textual classical ciphers decode the text as typed
+	if c := cfg.ClassicCipher(); c != nil && c.Textual() {
+		plaintext, err := c.Decode([]byte(data))
 		if err != nil {
-			// If hex fails, try base64
-			inputBytes, err = base64.StdEncoding.DecodeString(data)
+			return nil, err
+		}
+		return &Decrypted{Plaintext: plaintext, Format: "text"}, nil
+	}
+
+	inputBytes, format, err := decodeInput(data, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return openBytes(inputBytes, format, cfg)
+}
+
+// decodeInput turns encoded input into bytes, using the configured input
+// format or the best detected candidate. With encryption on, every candidate
+// is tried in order and the first one that authenticates wins.
+func decodeInput(data string, cfg *config.Config) ([]byte, string, error) {
+	if cfg.InputFormat != "" && cfg.InputFormat != "auto" {
+		decoded, err := DecodeFormat(data, cfg.InputFormat)
+		return decoded, cfg.InputFormat, err
+	}
+
+	candidates := DetectFormat(data, cfg.UseEncryption)
+	if len(candidates) == 0 {
+		return nil, "", fmt.Errorf("%w (expected %s); use --input-format to choose one",
+			ErrUnknownFormat, strings.Join(InputFormats()[1:len(InputFormats())-1], ", "))
+	}
+
+	if cfg.UseEncryption {
+		for _, c := range candidates {
+			payload, _, err := RemoveFEC(c.Bytes)
 			if err != nil {
-				// If base64 fails, treat as raw binary
-				inputBytes = []byte(data)
+				continue
+			}
+			if _, err := OpenWith(cfg.Cipher, cfg.Key, payload); err == nil {
+				return c.Bytes, c.Format, nil
 			}
 		}
 	}
-	
+	return candidates[0].Bytes, candidates[0].Format, nil
+}
+
+// openBytes repairs, then decrypts (or passes through) already decoded input bytes
+func openBytes(inputBytes []byte, format string, cfg *config.Config) (*Decrypted, error) {
+	inputBytes, corrected, err := RemoveFEC(inputBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var plaintext []byte
 	if cfg.UseEncryption {
-		// AES-GCM decryption
-		block, err := aes.NewCipher(cfg.Key)
-		if err != nil {
-			return "", fmt.Errorf("failed to create cipher: %w", err)
-		}
-		
-		gcm, err := cipher.NewGCM(block)
-		if err != nil {
-			return "", fmt.Errorf("failed to create GCM: %w", err)
-		}
-		
-		nonceSize := gcm.NonceSize()
-		if len(inputBytes) < nonceSize {
-			return "", fmt.Errorf("ciphertext too short")
-		}
-		
-		nonce, ciphertext := inputBytes[:nonceSize], inputBytes[nonceSize:]
-		plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
-		if err != nil {
-			return "", fmt.Errorf("failed to decrypt data: %w", err)
-		}
-		
-		return string(plaintext), nil
+		plaintext, err = OpenWith(cfg.Cipher, cfg.Key, inputBytes)
+	} else if c := cfg.ClassicCipher(); c != nil {
+		// Plain decoding - undo the classical cipher if one is selected
+		plaintext, err = c.Decode(inputBytes)
 	} else {
-		// Plain decoding - just convert back to string
-		return string(inputBytes), nil
+		plaintext = inputBytes
+	}
+	if err != nil {
+		return nil, err
 	}
+	return &Decrypted{Plaintext: plaintext, Format: format, Corrected: corrected}, nil
+}
+
+// displayText returns plaintext as a string, or a hexdump when it isn't valid UTF-8
+func displayText(plaintext []byte) string {
+	if utf8.Valid(plaintext) {
+		return string(plaintext)
+	}
+	return "\n" + Hexdump(plaintext)
+}
+
+// Open decrypts data produced by Seal
+func Open(key, data []byte) ([]byte, error) {
+	return OpenWith("aes-gcm", key, data)
+}
+
+// OpenWith decrypts data produced by SealWith with the same cipher
+func OpenWith(name string, key, data []byte) ([]byte, error) {
+	aead, err := newAEAD(name, key)
+	if err != nil {
+		return nil, err
+	}
+	
+	nonceSize := aead.NonceSize()
+	if len(data) < nonceSize+aead.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+	
+	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", ErrAuthenticationFailed)
+	}
+	
+	return plaintext, nil
 }
 
 // parseBinaryString converts a string of 0s and 1s to bytes
@@ -0,0 +1,89 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The binary data type reads files, hex and base64 as raw bytes, unchanged
+func TestReadInputBinary(t *testing.T) {
+	data := make([]byte, 256)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	data = append(data, " trailing space and CRLF\r\n"...)
+	path := filepath.Join(t.TempDir(), "payload.bin")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testConfig(t, "correct horse battery staple")
+	if !cfg.SetDataType("binary") {
+		t.Fatal("binary data type rejected")
+	}
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"file", path},
+		{"file with surrounding space", "  " + path + "\n"},
+		{"hex", hex.EncodeToString(data)},
+		{"base64", base64.StdEncoding.EncodeToString(data)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadInput(tt.input, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("got %q, want %q", got, data)
+			}
+		})
+	}
+
+	if _, err := ReadInput("not a file, hex or base64!", cfg); err == nil || !strings.Contains(err.Error(), "binary mode expects") {
+		t.Errorf("got %v, want a binary mode error", err)
+	}
+}
+
+func TestReadInputText(t *testing.T) {
+	cfg := testConfig(t, "correct horse battery staple")
+	for _, input := range []string{"48656c6c6f", "  spaced \n", os.DevNull} {
+		got, err := ReadInput(input, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != input {
+			t.Errorf("text mode read %q as %q", input, got)
+		}
+	}
+}
+
+// Non-UTF-8 plaintext survives encryption and is displayed as a hexdump
+func TestBinaryRoundTrip(t *testing.T) {
+	data := []byte{0x00, 0xff, 0xfe, '\n', 0x80, 'a'}
+	cfg := testConfig(t, "correct horse battery staple")
+	cfg.SetDataType("binary")
+	cfg.SetEncryption(true)
+
+	encrypted, err := EncryptData(hex.EncodeToString(data), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Decrypt(encrypted, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result.Plaintext, data) {
+		t.Errorf("got %x, want %x", result.Plaintext, data)
+	}
+	if got := displayText(result.Plaintext); got != "\n"+Hexdump(data) {
+		t.Errorf("displayed as %q", got)
+	}
+}
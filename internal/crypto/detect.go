@@ -0,0 +1,214 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FormatCandidate is one possible interpretation of encoded input
+type FormatCandidate struct {
+	Format     string
+	Confidence float64 // 0..1
+	Bytes      []byte
+	syntax     float64 // Confidence from the shape of the input alone
+}
+
+// inputFormat decodes one encoding and rates how specific the match is
+type inputFormat struct {
+	name   string
+	decode func(data string) ([]byte, error)
+	// syntax returns the base confidence for input that decoded successfully;
+	// zero means the input shows no sign of the format
+	syntax func(data string) float64
+}
+
+// AmbiguousMargin is how close the best two candidates may score before
+// detection counts as a guess rather than a match
+const AmbiguousMargin = 0.15
+
+// Decoded output shorter than this is too little text for printability to
+// tell formats apart
+const minPrintableEvidence = 16
+
+// Minimum AES-GCM envelope: 12 byte nonce + 16 byte tag
+const minSealedSize = 28
+
+var inputFormats = []inputFormat{
+	{
+		name: "armor",
+		decode: func(data string) ([]byte, error) {
+			payload, _, err := Dearmor(data)
+			return payload, err
+		},
+		syntax: func(data string) float64 { return 0.99 },
+	},
+	{
+		name:   "hexdump",
+		decode: ParseHexdump,
+		syntax: func(data string) float64 {
+			// Plain `xxd -p` output is already covered by hex
+			if strings.Contains(data, "0x") || xxdOffset.MatchString(data) {
+				return 0.97
+			}
+			return 0.5
+		},
+	},
+	{
+		name:   "binary",
+		decode: func(data string) ([]byte, error) { return parseBinaryString(data) },
+		syntax: func(data string) float64 { return 0.95 },
+	},
+	{
+		name:   "hex",
+		decode: func(data string) ([]byte, error) { return hex.DecodeString(stripSpace(data)) },
+		syntax: func(data string) float64 { return 0.9 },
+	},
+	{
+		name:   "base64",
+		decode: func(data string) ([]byte, error) { return base64.StdEncoding.DecodeString(stripSpace(data)) },
+		syntax: func(data string) float64 {
+			// Strings made only of hex digits are valid base64 too, but hex is far more likely
+			if strings.ContainsAny(data, "+/=") || strings.IndexFunc(data, func(r rune) bool {
+				return !strings.ContainsRune("0123456789abcdefABCDEF", r) && !unicode.IsSpace(r)
+			}) >= 0 {
+				return 0.85
+			}
+			return 0.3
+		},
+	},
+	{
+		name:   "url",
+		decode: DecodeURL,
+		syntax: hasEscapes(percentEscape, 0.8),
+	},
+	{
+		name:   "html",
+		decode: DecodeHTML,
+		syntax: hasEscapes(htmlEntity, 0.8),
+	},
+	{
+		name:   "unicode",
+		decode: DecodeUnicode,
+		syntax: hasEscapes(unicodeEscape, 0.9),
+	},
+	{
+		name:   "qp",
+		decode: DecodeQuotedPrintable,
+		syntax: hasEscapes(qpEscape, 0.6),
+	},
+}
+
+// InputFormats lists the formats accepted by --input-format
+func InputFormats() []string {
+	names := []string{"auto"}
+	for _, f := range inputFormats {
+		names = append(names, f.name)
+	}
+	return append(names, "text")
+}
+
+// DetectFormat scores every known input format and returns those that
+// decode, most likely first. When sealed is true the decoded bytes must be
+// large enough to hold an AES-GCM envelope; otherwise readable output is
+// preferred. Short input that fits several formats, such as "01100001" as
+// binary or hex, is ranked but reported by Ambiguous.
+func DetectFormat(data string, sealed bool) []FormatCandidate {
+	candidates := decodeCandidates(data)
+	for i := range candidates {
+		if sealed {
+			if len(candidates[i].Bytes) < minSealedSize {
+				candidates[i].Confidence *= 0.3
+			}
+		} else {
+			candidates[i].Confidence *= 0.4 + 0.6*printableRatio(candidates[i].Bytes)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Confidence > candidates[j].Confidence })
+	return candidates
+}
+
+// decodeCandidates returns every format that decodes data, scored on syntax alone
+func decodeCandidates(data string) []FormatCandidate {
+	data = strings.TrimSpace(data)
+	if data == "" {
+		return nil
+	}
+
+	var candidates []FormatCandidate
+	for _, f := range inputFormats {
+		decoded, err := f.decode(data)
+		if err != nil || len(decoded) == 0 {
+			continue
+		}
+		confidence := f.syntax(data)
+		if confidence == 0 {
+			continue
+		}
+		candidates = append(candidates, FormatCandidate{Format: f.name, Confidence: confidence, Bytes: decoded, syntax: confidence})
+	}
+	return candidates
+}
+
+// DecodeFormat decodes data in a specific format, bypassing detection
+func DecodeFormat(data, format string) ([]byte, error) {
+	if format == "text" {
+		return []byte(data), nil
+	}
+	for _, f := range inputFormats {
+		if f.name == format {
+			decoded, err := f.decode(strings.TrimSpace(data))
+			if err != nil {
+				return nil, fmt.Errorf("input is not valid %s: %w", format, err)
+			}
+			return decoded, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown input format: %s", format)
+}
+
+// Ambiguous reports whether the best candidate did not clearly beat the others
+func Ambiguous(candidates []FormatCandidate) bool {
+	if len(candidates) < 2 {
+		return false
+	}
+	best := candidates[0]
+	if best.Confidence-candidates[1].Confidence <= AmbiguousMargin {
+		return true
+	}
+	// A few printable bytes prove little, so short input only clearly
+	// belongs to one format when its syntax says so
+	if len(best.Bytes) < minPrintableEvidence {
+		for _, c := range candidates[1:] {
+			if best.syntax-c.syntax <= AmbiguousMargin {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// printableRatio is the share of data that is printable UTF-8 text
+func printableRatio(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	total, printable := len(data), 0
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r != utf8.RuneError && (unicode.IsPrint(r) || unicode.IsSpace(r)) {
+			printable += size
+		}
+		data = data[size:]
+	}
+	return float64(printable) / float64(total)
+}
+
+func stripSpace(data string) string {
+	return strings.Join(strings.Fields(data), "")
+}
@@ -0,0 +1,109 @@
+package crypto
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		sealed    bool
+		format    string // best candidate
+		bytes     string
+		ambiguous bool
+	}{
+		{"hex", "48656c6c6f", false, "hex", "Hello", false},
+		{"hex with spaces", "48 65 6c 6c 6f", false, "hex", "Hello", false},
+		{"base64", "SGVsbG8=", false, "base64", "Hello", false},
+		{"base64 without padding chars", "SGVsbG8gd29ybGQh", false, "base64", "Hello world!", false},
+		{"binary", "01101000 01101001", false, "binary", "hi", true},
+		{"binary or hex", "01100001", false, "binary", "a", true},
+		{"binary or hex, sealed", "01100001", true, "binary", "a", true},
+		{"long binary", strings.Repeat("01100001", 16), false, "binary", strings.Repeat("a", 16), false},
+		{"unprintable hex", "deadbeef", false, "hex", "\xde\xad\xbe\xef", false},
+		{"hex digits as base64", "cafe", false, "hex", "\xca\xfe", false},
+		{"xxd", "00000000: 4865 6c6c 6f  Hello", false, "hexdump", "Hello", false},
+		{"url", "Hello%20world", false, "url", "Hello world", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := DetectFormat(tt.data, tt.sealed)
+			if len(candidates) == 0 {
+				t.Fatal("no candidates")
+			}
+			if got := candidates[0]; got.Format != tt.format || string(got.Bytes) != tt.bytes {
+				t.Errorf("best candidate is %s %q, want %s %q", got.Format, got.Bytes, tt.format, tt.bytes)
+			}
+			if got := Ambiguous(candidates); got != tt.ambiguous {
+				t.Errorf("Ambiguous = %v, want %v (candidates %v)", got, tt.ambiguous, formats(candidates))
+			}
+			for i := 1; i < len(candidates); i++ {
+				if candidates[i].Confidence > candidates[i-1].Confidence {
+					t.Errorf("candidates out of order: %v", formats(candidates))
+				}
+			}
+		})
+	}
+}
+
+func formats(candidates []FormatCandidate) []string {
+	var names []string
+	for _, c := range candidates {
+		names = append(names, c.Format)
+	}
+	return names
+}
+
+// Input that decodes in several formats lists each of them
+func TestDetectFormatCollisions(t *testing.T) {
+	tests := []struct {
+		data string
+		want []string
+	}{
+		{"01100001", []string{"binary", "hex", "base64"}},
+		{"deadbeef", []string{"hex", "base64"}},
+		{"SGVsbG8=", []string{"base64"}},
+	}
+	for _, tt := range tests {
+		got := make(map[string]bool)
+		for _, c := range DetectFormat(tt.data, false) {
+			got[c.Format] = true
+		}
+		for _, f := range tt.want {
+			if !got[f] {
+				t.Errorf("%q: %s is not a candidate", tt.data, f)
+			}
+		}
+	}
+}
+
+func TestDetectFormatNothing(t *testing.T) {
+	for _, data := range []string{"", "   ", "not any format!"} {
+		if got := DetectFormat(data, false); len(got) != 0 {
+			t.Errorf("%q: got candidates %v", data, formats(got))
+		}
+	}
+}
+
+func TestDecodeFormat(t *testing.T) {
+	// Skipping detection reads the input as the named format
+	got, err := DecodeFormat("01100001", "hex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, []byte{0x01, 0x10, 0x00, 0x01}) {
+		t.Errorf("got %x", got)
+	}
+	if got, _ := DecodeFormat("zz", "text"); string(got) != "zz" {
+		t.Errorf("text gave %q", got)
+	}
+	if _, err := DecodeFormat("zz", "hex"); err == nil || !strings.Contains(err.Error(), "not valid hex") {
+		t.Errorf("got %v, want a not valid hex error", err)
+	}
+	if _, err := DecodeFormat("zz", "rot13"); err == nil || !strings.Contains(err.Error(), "unknown input format") {
+		t.Errorf("got %v, want an unknown format error", err)
+	}
+}
@@ -0,0 +1,14 @@
+package crypto
+
+import "errors"
+
+// Errors returned by decryption, for use with errors.Is. Messages are
+// usually wrapped with context, so compare with errors.Is rather than ==.
+var (
+	// ErrAuthenticationFailed means the key is wrong or the ciphertext was altered
+	ErrAuthenticationFailed = errors.New("message authentication failed")
+	// ErrUnknownFormat means no known input format decoded the data
+	ErrUnknownFormat = errors.New("could not determine input format")
+	// ErrCiphertextTooShort means the data is too short to hold a nonce and tag
+	ErrCiphertextTooShort = errors.New("ciphertext too short")
+)
@@ -0,0 +1,87 @@
+package crypto
+
+import (
+	"fmt"
+
+	"doc0x1/text2babe/internal/rs"
+)
+
+// FEC framing: "T2F" followed by the parity count written three times, then
+// the data in blocks of up to 255-n bytes, each followed by n Reed-Solomon
+// parity bytes. A block repairs up to n/2 corrupted bytes. The header has
+// no parity of its own: one damaged magic byte is tolerated and the count is
+// decided by majority vote.
+
+var fecMagic = []byte("T2F")
+
+const fecHeaderSize = 6
+
+// AddFEC frames data with n parity bytes per block
+func AddFEC(data []byte, n int) []byte {
+	k := 255 - n
+	out := append(append([]byte(nil), fecMagic...), byte(n), byte(n), byte(n))
+	for len(data) > 0 {
+		block := data[:min(k, len(data))]
+		data = data[len(block):]
+		out = append(out, block...)
+		out = append(out, rs.Encode(block, n)...)
+	}
+	return out
+}
+
+// fecParity returns the parity count from a frame header, or 0 if data is
+// not framed, and how many header bytes were damaged
+func fecParity(data []byte) (n, damaged int) {
+	if len(data) < fecHeaderSize {
+		return 0, 0
+	}
+	for i, m := range fecMagic {
+		if data[i] != m {
+			damaged++
+		}
+	}
+	if damaged > 1 {
+		return 0, 0
+	}
+
+	a, b, c := data[3], data[4], data[5]
+	count := a
+	if b == c {
+		count = b
+	}
+	if count < 2 || count > 128 {
+		return 0, 0
+	}
+	for _, v := range []byte{a, b, c} {
+		if v != count {
+			damaged++
+		}
+	}
+	return int(count), damaged
+}
+
+// RemoveFEC corrects and strips an FEC frame. Unframed data is returned as
+// is. The count is the number of bytes repaired.
+func RemoveFEC(data []byte) ([]byte, int, error) {
+	n, corrected := fecParity(data)
+	if n == 0 {
+		return data, 0, nil
+	}
+
+	body := data[fecHeaderSize:]
+	var out []byte
+	for block := 1; len(body) > 0; block++ {
+		size := min(255, len(body))
+		if size <= n {
+			return nil, 0, fmt.Errorf("FEC block %d is truncated", block)
+		}
+		decoded, fixed, err := rs.Decode(body[:size], n)
+		if err != nil {
+			return nil, 0, fmt.Errorf("FEC block %d: %w (more than %d bytes damaged)", block, err, n/2)
+		}
+		out = append(out, decoded...)
+		corrected += fixed
+		body = body[size:]
+	}
+	return out, corrected, nil
+}
@@ -0,0 +1,127 @@
+package crypto
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestFECRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tests := []struct {
+		name string
+		size int
+		n    int
+	}{
+		{"one byte", 1, 4},
+		{"one block", 200, 16},
+		{"exactly one block", 255 - 32, 32},
+		{"several blocks", 1000, 32},
+		{"high parity", 600, 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := make([]byte, tt.size)
+			rng.Read(data)
+			framed := AddFEC(data, tt.n)
+
+			got, corrected, err := RemoveFEC(framed)
+			if err != nil {
+				t.Fatalf("clean frame: %v", err)
+			}
+			if corrected != 0 || !bytes.Equal(got, data) {
+				t.Fatalf("clean frame: %d corrections, data equal %v", corrected, bytes.Equal(got, data))
+			}
+
+			// Damage n/2 bytes in every block, the most each block can repair
+			damaged := append([]byte(nil), framed...)
+			want := 0
+			for start := fecHeaderSize; start < len(damaged); start += 255 {
+				size := min(255, len(damaged)-start)
+				for _, pos := range rng.Perm(size)[:tt.n/2] {
+					damaged[start+pos] ^= 0x5a
+					want++
+				}
+			}
+			got, corrected, err = RemoveFEC(damaged)
+			if err != nil {
+				t.Fatalf("damaged frame: %v", err)
+			}
+			if corrected != want {
+				t.Errorf("damaged frame: %d corrections, want %d", corrected, want)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatal("damaged frame: data not recovered")
+			}
+
+			// One more damaged byte in the first block is beyond repair
+			for _, pos := range rng.Perm(min(255, len(framed)-fecHeaderSize))[:tt.n/2+1] {
+				framed[fecHeaderSize+pos] ^= 0xa5
+			}
+			if _, _, err := RemoveFEC(framed); err == nil {
+				t.Errorf("%d damaged bytes with %d parity bytes: no error", tt.n/2+1, tt.n)
+			}
+		})
+	}
+}
+
+func TestFECHeaderDamage(t *testing.T) {
+	data := []byte("attack at dawn, bring snacks")
+	framed := AddFEC(data, 8)
+	tests := []struct {
+		name      string
+		pos       int
+		value     byte
+		framed    bool // still recognized as a frame
+		corrected int
+	}{
+		{"magic typo", 0, 'X', true, 1},
+		{"magic last byte", 2, 'G', true, 1},
+		{"one count byte", 4, 99, true, 1},
+		{"count out of range", 3, 200, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			damaged := append([]byte(nil), framed...)
+			damaged[tt.pos] = tt.value
+			got, corrected, err := RemoveFEC(damaged)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("got %q, want %q", got, data)
+			}
+			if corrected != tt.corrected {
+				t.Errorf("%d corrections, want %d", corrected, tt.corrected)
+			}
+		})
+	}
+}
+
+func TestFECUnframed(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"plain text", []byte("hello, world")},
+		{"shorter than a header", []byte("T2F")},
+		{"two magic bytes wrong", append([]byte("TXG"), 8, 8, 8, 1, 2, 3)},
+		{"no parity count agrees", append([]byte("T2F"), 1, 200, 0, 1, 2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, corrected, err := RemoveFEC(tt.data)
+			if err != nil || corrected != 0 || !bytes.Equal(got, tt.data) {
+				t.Errorf("RemoveFEC(%q) = %q, %d, %v; want it unchanged", tt.data, got, corrected, err)
+			}
+		})
+	}
+}
+
+func TestFECTruncated(t *testing.T) {
+	framed := AddFEC([]byte(strings.Repeat("x", 300)), 16)
+	if _, _, err := RemoveFEC(framed[:len(framed)-40]); err == nil {
+		t.Error("truncated frame decoded without error")
+	}
+}
@@ -0,0 +1,189 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const hexdumpWidth = 16
+
+// Hexdump renders data like `xxd`: offset, hex in 2-byte groups, ASCII column
+func Hexdump(data []byte) string {
+	var b strings.Builder
+	for off := 0; off < len(data); off += hexdumpWidth {
+		line := data[off:min(off+hexdumpWidth, len(data))]
+
+		var hexCol strings.Builder
+		for i, c := range line {
+			if i > 0 && i%2 == 0 {
+				hexCol.WriteByte(' ')
+			}
+			fmt.Fprintf(&hexCol, "%02x", c)
+		}
+
+		if off > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%08x: %-39s  %s", off, hexCol.String(), hexdumpASCII(line))
+	}
+	return b.String()
+}
+
+// hexdumpASCII renders the ASCII column, with a dot for unprintable bytes
+func hexdumpASCII(line []byte) string {
+	ascii := make([]byte, len(line))
+	for i, c := range line {
+		if c >= 0x20 && c < 0x7f {
+			ascii[i] = c
+		} else {
+			ascii[i] = '.'
+		}
+	}
+	return string(ascii)
+}
+
+var (
+	// xxdOffset matches the "00000010: " prefix of an xxd line, even when
+	// the lines were joined into one (e.g. typed into the shell)
+	xxdOffset = regexp.MustCompile(`(?:^|\s)[0-9a-fA-F]{4,16}: `)
+	cByte     = regexp.MustCompile(`^0[xX][0-9a-fA-F]{1,2}$`)
+)
+
+// ParseHexdump reads bytes back from xxd output (offsets and the ASCII column
+// are ignored), `xxd -p` plain hex or a C array such as `{0x41, 0x42}`
+func ParseHexdump(text string) ([]byte, error) {
+	text = strings.TrimSpace(text)
+	switch {
+	case xxdOffset.MatchString(text):
+		return parseXXD(text)
+	case strings.Contains(text, "0x") || strings.Contains(text, "0X"):
+		return parseCArray(text)
+	default:
+		return hex.DecodeString(stripSpace(text))
+	}
+}
+
+func parseXXD(text string) ([]byte, error) {
+	var out []byte
+	locs := xxdOffset.FindAllStringIndex(text, -1)
+	limit := hexdumpWidth
+	for i, loc := range locs {
+		end := len(text)
+		last := i+1 == len(locs)
+		if !last {
+			end = locs[i+1][0]
+			// The next offset says how many bytes this line holds, so an ASCII
+			// column that happens to be valid hex is never read as data
+			if n := xxdLineOffset(text, locs[i+1]) - xxdLineOffset(text, loc); n > 0 && n <= 256 {
+				limit = n
+			}
+		}
+		line := strings.TrimRight(text[loc[1]:end], "\r\n")
+
+		// xxd separates the hex and ASCII columns with two spaces
+		var lineBytes []byte
+		if hexPart, _, ok := strings.Cut(line, "  "); ok {
+			lineBytes = hexGroups(strings.Fields(hexPart), limit)
+		} else if last {
+			lineBytes = lastXXDLine(line, limit)
+		} else {
+			lineBytes = hexGroups(strings.Fields(line), limit)
+		}
+		if len(lineBytes) == 0 {
+			return nil, fmt.Errorf("hexdump line %d has no hex data", i+1)
+		}
+		out = append(out, lineBytes...)
+	}
+	return out, nil
+}
+
+// xxdLineOffset parses the offset of the xxd line prefix at loc
+func xxdLineOffset(text string, loc []int) int {
+	off, err := strconv.ParseUint(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text[loc[0]:loc[1]]), ":")), 16, 32)
+	if err != nil {
+		return -1
+	}
+	return int(off)
+}
+
+// hexGroups decodes hex groups until one fails or limit bytes are read
+func hexGroups(groups []string, limit int) []byte {
+	var out []byte
+	for _, group := range groups {
+		decoded, err := hex.DecodeString(group)
+		if err != nil || len(out)+len(decoded) > limit {
+			break
+		}
+		out = append(out, decoded...)
+	}
+	return out
+}
+
+// lastXXDLine reads a final xxd line whose ASCII column lost its two-space
+// separator. xxd writes 4-digit groups (2 digits for an odd last byte)
+// followed by the ASCII column, so the data is the run of groups whose
+// remainder is their own ASCII rendering. Without an ASCII column every
+// group is taken.
+func lastXXDLine(line string, limit int) []byte {
+	var groups []string
+	var ends []int // Position in line after each group
+	pos := 0
+	for len(groups)*2 < limit {
+		rest := strings.TrimLeft(line[pos:], " \t")
+		field, _, _ := strings.Cut(rest, " ")
+		if !isXXDGroup(field) {
+			break
+		}
+		pos = len(line) - len(rest) + len(field)
+		groups = append(groups, field)
+		ends = append(ends, pos)
+		if len(field) == 2 {
+			break
+		}
+	}
+
+	for k := len(groups); k > 0; k-- {
+		data := hexGroups(groups[:k], limit)
+		ascii := strings.TrimSpace(line[ends[k-1]:])
+		if ascii != "" && ascii == strings.TrimSpace(hexdumpASCII(data)) {
+			return data
+		}
+	}
+	return hexGroups(groups, limit)
+}
+
+func isXXDGroup(s string) bool {
+	if len(s) != 4 && len(s) != 2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// parseCArray accepts the body of `xxd -i` output or a bare list of 0xHH values
+func parseCArray(text string) ([]byte, error) {
+	if start := strings.Index(text, "{"); start >= 0 {
+		end := strings.LastIndex(text, "}")
+		if end < start {
+			return nil, fmt.Errorf("unterminated C array")
+		}
+		text = text[start+1 : end]
+	}
+
+	var out []byte
+	for _, item := range strings.Split(text, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !cByte.MatchString(item) {
+			return nil, fmt.Errorf("invalid C array element %q", item)
+		}
+		v, _ := strconv.ParseUint(item[2:], 16, 8)
+		out = append(out, byte(v))
+	}
+	return out, nil
+}
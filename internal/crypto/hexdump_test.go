@@ -0,0 +1,118 @@
+package crypto
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestHexdumpRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, size := range []int{1, 2, 15, 16, 17, 31, 32, 33, 300} {
+		data := make([]byte, size)
+		rng.Read(data)
+		dump := Hexdump(data)
+
+		forms := map[string]string{
+			"xxd":                  dump,
+			"joined onto one line": strings.ReplaceAll(dump, "\n", " "),
+			"CRLF":                 strings.ReplaceAll(dump, "\n", "\r\n"),
+		}
+		for name, text := range forms {
+			got, err := ParseHexdump(text)
+			if err != nil {
+				t.Fatalf("%d bytes, %s: %v", size, name, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("%d bytes, %s: got %x, want %x", size, name, got, data)
+			}
+		}
+	}
+}
+
+func TestHexdumpFormat(t *testing.T) {
+	got := Hexdump([]byte("Hello, hexdump!\x00\x7f"))
+	want := "00000000: 4865 6c6c 6f2c 2068 6578 6475 6d70 2100  Hello, hexdump!.\n" +
+		"00000010: 7f                                       ."
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParseHexdumpForms(t *testing.T) {
+	want := []byte("AB\x00\xff")
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"plain hex", "414200ff"},
+		{"spaced hex", "41 42 00 ff\n"},
+		{"upper case", "414200FF"},
+		{"C array", "unsigned char data[] = {\n  0x41, 0x42, 0x00, 0xff\n};"},
+		{"bare C list", "0x41, 0x42, 0x0, 0XFF"},
+		// ASCII column made of hex digits must not be read as data
+		{"hex-like ASCII column", "00000000: 4142 00ff  AB.."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHexdump(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("got %x, want %x", got, want)
+			}
+		})
+	}
+}
+
+// When the two spaces before the ASCII column are lost, an ASCII column made
+// of hex digits is still not read as data
+func TestParseHexdumpLostSeparator(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"00000000: 3132 3334 1234", "1234"},
+		{"00000000: 3132 3334 3536 123456", "123456"},
+		{"00000000: 3132 33 123", "123"},
+		{"00000000: 6869 2021 hi !", "hi !"},
+		{"00000000: 6869 21", "hi!"},
+	}
+	for _, tt := range tests {
+		got, err := ParseHexdump(tt.text)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.text, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("ParseHexdump(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	// Every line of a dump of hex digits, with all spacing collapsed
+	data := []byte("0123456789abcdef0123456789abcdef01234567cafe")
+	text := strings.Join(strings.Fields(Hexdump(data)), " ")
+	got, err := ParseHexdump(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("%q: got %q, want %q", text, got, data)
+	}
+}
+
+func TestParseHexdumpRejects(t *testing.T) {
+	for _, text := range []string{
+		"41424",
+		"not hex at all",
+		"{0x41, 0x4g}",
+		"{0x41, 0x42",
+		fmt.Sprintf("%08x: zz", 0),
+	} {
+		if got, err := ParseHexdump(text); err == nil {
+			t.Errorf("ParseHexdump(%q) = %x, want an error", text, got)
+		}
+	}
+}
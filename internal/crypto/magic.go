@@ -0,0 +1,140 @@
+package crypto
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"math"
+	"sort"
+	"unicode/utf8"
+)
+
+// Limits for the magic search so hostile input can't explode it
+const (
+	magicMaxDepth = 8
+	magicMaxNodes = 512
+	magicMaxSize  = 1 << 20
+)
+
+// MagicResult is a readable output reached by a chain of decoding steps
+type MagicResult struct {
+	Steps     []string
+	Data      []byte
+	Printable float64 // Share of printable UTF-8 (0..1)
+	Entropy   float64 // Shannon entropy in bits per byte
+}
+
+type magicNode struct {
+	data  []byte
+	steps []string
+}
+
+// Magic recursively peels encodings (binary, hex, base64), compression
+// (gzip, zlib) and, when key is not nil, a layer sealed with cipher. Every
+// intermediate result is scored for printability and entropy; readable
+// results are returned with the deepest (most fully decoded) chains first,
+// then the most printable, then the lowest entropy (text that is still
+// encoded looks more random than the message inside it).
+func Magic(data, cipher string, key []byte) []MagicResult {
+	seen := map[string]bool{data: true}
+	queue := []magicNode{{data: []byte(data)}}
+	var results []MagicResult
+
+	for nodes := 0; len(queue) > 0 && nodes < magicMaxNodes; nodes++ {
+		node := queue[0]
+		queue = queue[1:]
+
+		if len(node.steps) > 0 {
+			if r := scoreMagic(node); r.Printable >= 0.9 {
+				results = append(results, r)
+			}
+		}
+		if len(node.steps) >= magicMaxDepth {
+			continue
+		}
+
+		for _, next := range magicSteps(node.data, cipher, key) {
+			if len(next.data) == 0 || len(next.data) > magicMaxSize || seen[string(next.data)] {
+				continue
+			}
+			seen[string(next.data)] = true
+			next.steps = append(append([]string{}, node.steps...), next.steps...)
+			queue = append(queue, next)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if len(results[i].Steps) != len(results[j].Steps) {
+			return len(results[i].Steps) > len(results[j].Steps)
+		}
+		if results[i].Printable != results[j].Printable {
+			return results[i].Printable > results[j].Printable
+		}
+		return results[i].Entropy < results[j].Entropy
+	})
+	return results
+}
+
+// magicSteps lists every single operation that applies to data
+func magicSteps(data []byte, cipher string, key []byte) []magicNode {
+	var next []magicNode
+
+	if utf8.Valid(data) {
+		for _, c := range decodeCandidates(string(data)) {
+			next = append(next, magicNode{data: c.Bytes, steps: []string{c.Format}})
+		}
+	}
+
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		if r, err := gzip.NewReader(bytes.NewReader(data)); err == nil {
+			if out, err := io.ReadAll(io.LimitReader(r, magicMaxSize+1)); err == nil {
+				next = append(next, magicNode{data: out, steps: []string{"gunzip"}})
+			}
+		}
+	}
+
+	if len(data) > 2 && data[0] == 0x78 && (uint16(data[0])<<8|uint16(data[1]))%31 == 0 {
+		if r, err := zlib.NewReader(bytes.NewReader(data)); err == nil {
+			if out, err := io.ReadAll(io.LimitReader(r, magicMaxSize+1)); err == nil {
+				next = append(next, magicNode{data: out, steps: []string{"zlib"}})
+			}
+		}
+	}
+
+	if key != nil && len(data) >= minSealedSize {
+		if out, err := OpenWith(cipher, key, data); err == nil {
+			next = append(next, magicNode{data: out, steps: []string{cipher}})
+		}
+	}
+
+	return next
+}
+
+func scoreMagic(node magicNode) MagicResult {
+	return MagicResult{
+		Steps:     node.steps,
+		Data:      node.data,
+		Printable: printableRatio(node.data),
+		Entropy:   entropy(node.data),
+	}
+}
+
+// entropy returns the Shannon entropy of data in bits per byte
+func entropy(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+	e := 0.0
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / float64(len(data))
+			e -= p * math.Log2(p)
+		}
+	}
+	return e
+}
@@ -0,0 +1,103 @@
+package crypto
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestMagicChains(t *testing.T) {
+	message := []byte("the quick brown fox jumps over the lazy dog")
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write(message)
+	w.Close()
+
+	tests := []struct {
+		name  string
+		data  string
+		steps string // plain hex is also read by hexdump, which is tried first
+	}{
+		{"base64 of hex", base64.StdEncoding.EncodeToString([]byte(hex.EncodeToString(message))), "base64 hexdump"},
+		{"hex of base64", hex.EncodeToString([]byte(base64.StdEncoding.EncodeToString(message))), "hexdump base64"},
+		{"base64 of gzip", base64.StdEncoding.EncodeToString(gz.Bytes()), "base64 gunzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := Magic(tt.data, "aes-gcm", nil)
+			if len(results) == 0 {
+				t.Fatal("no results")
+			}
+			best := results[0]
+			if got := strings.Join(best.Steps, " "); got != tt.steps || !bytes.Equal(best.Data, message) {
+				t.Errorf("best chain %s gave %q, want %s", got, best.Data, tt.steps)
+			}
+		})
+	}
+}
+
+// The encryption layer is opened with the configured cipher
+func TestMagicCipher(t *testing.T) {
+	key := sha256.Sum256([]byte("magic"))
+	message := []byte("attack at dawn")
+	for _, cipher := range []string{"aes-gcm", "chacha20-poly1305"} {
+		t.Run(cipher, func(t *testing.T) {
+			sealed, err := SealWith(cipher, key[:], message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data := base64.StdEncoding.EncodeToString(sealed)
+
+			results := Magic(data, cipher, key[:])
+			if len(results) == 0 || !bytes.Equal(results[0].Data, message) {
+				t.Fatalf("did not open the %s layer: %v", cipher, results)
+			}
+			if got := strings.Join(results[0].Steps, " "); got != "base64 "+cipher {
+				t.Errorf("steps %s", got)
+			}
+			if results := Magic(data, cipher, nil); len(results) != 0 && bytes.Equal(results[0].Data, message) {
+				t.Error("opened the layer without a key")
+			}
+		})
+	}
+}
+
+// Among equally deep, fully printable chains the lowest entropy comes first
+func TestMagicSortsByEntropy(t *testing.T) {
+	results := Magic(base64.StdEncoding.EncodeToString([]byte("68656c6c6f2068656c6c6f")), "aes-gcm", nil)
+	for i := 1; i < len(results); i++ {
+		a, b := results[i-1], results[i]
+		if len(a.Steps) == len(b.Steps) && a.Printable == b.Printable && a.Entropy > b.Entropy {
+			t.Errorf("%v (entropy %.2f) sorted before %v (entropy %.2f)", a.Steps, a.Entropy, b.Steps, b.Entropy)
+		}
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want float64
+	}{
+		{nil, 0},
+		{[]byte("aaaa"), 0},
+		{[]byte("abab"), 1},
+		{[]byte("abcdefgh"), 3},
+	}
+	for _, tt := range tests {
+		if got := entropy(tt.data); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("entropy(%q) = %f, want %f", tt.data, got, tt.want)
+		}
+	}
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	if got := entropy(all); math.Abs(got-8) > 1e-9 {
+		t.Errorf("entropy of every byte value = %f, want 8", got)
+	}
+}
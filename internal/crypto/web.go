@@ -0,0 +1,204 @@
+package crypto
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"mime/quotedprintable"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Web encodings: URL percent-encoding, HTML entities, JavaScript/JSON
+// escapes and MIME quoted-printable. Text without escapes is valid in every
+// one of them and decodes to itself; detection only picks these formats when
+// escapes are present (see hasEscapes).
+
+var (
+	percentEscape = regexp.MustCompile(`%[0-9a-fA-F]{2}`)
+	htmlEntity    = regexp.MustCompile(`&(#[0-9]+|#[xX][0-9a-fA-F]+|[a-zA-Z][a-zA-Z0-9]*);`)
+	unicodeEscape = regexp.MustCompile(`\\(u[0-9a-fA-F]{4}|x[0-9a-fA-F]{2})`)
+	qpEscape      = regexp.MustCompile(`=([0-9A-F]{2}|\r?\n)`)
+	numericEntity = regexp.MustCompile(`&#([0-9]+|[xX][0-9a-fA-F]+);`)
+)
+
+// EncodeURL percent-encodes every byte outside the RFC 3986 unreserved set
+func EncodeURL(data []byte) string {
+	var b strings.Builder
+	for _, c := range data {
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+		c == '-' || c == '_' || c == '.' || c == '~'
+}
+
+// DecodeURL undoes percent-encoding, treating '+' as a space as forms do
+func DecodeURL(data string) ([]byte, error) {
+	decoded, err := url.QueryUnescape(data)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(decoded), nil
+}
+
+// EncodeHTML escapes markup characters and everything outside printable
+// ASCII as numeric entities. Entities describe characters, not bytes, so a
+// byte that isn't valid UTF-8 (always 0x80 or above) is written as the lone
+// surrogate U+DC80..U+DCFF, as Python's surrogateescape does; DecodeHTML
+// turns those back into the bytes.
+func EncodeHTML(data []byte) string {
+	var b strings.Builder
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		switch {
+		case r == utf8.RuneError && size <= 1:
+			fmt.Fprintf(&b, "&#xDC%02X;", data[0])
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case r == '"':
+			b.WriteString("&quot;")
+		case r == '\'':
+			b.WriteString("&#39;")
+		case r < 0x20 || r >= 0x7f:
+			fmt.Fprintf(&b, "&#x%X;", r)
+		default:
+			b.WriteRune(r)
+		}
+		data = data[size:]
+	}
+	return b.String()
+}
+
+// DecodeHTML resolves named and numeric character references. Numeric ones
+// are taken literally rather than with HTML's legacy remapping (which turns
+// &#x80; into €), and U+DC80..U+DCFF become the bytes EncodeHTML escaped.
+func DecodeHTML(data string) ([]byte, error) {
+	var out []byte
+	for i := 0; i < len(data); {
+		loc := numericEntity.FindStringSubmatchIndex(data[i:])
+		if loc == nil {
+			out = append(out, html.UnescapeString(data[i:])...)
+			break
+		}
+		out = append(out, html.UnescapeString(data[i:i+loc[0]])...)
+		ref := data[i+loc[2] : i+loc[3]]
+		i += loc[1]
+
+		var v uint64
+		var err error
+		if ref[0] == 'x' || ref[0] == 'X' {
+			v, err = strconv.ParseUint(ref[1:], 16, 32)
+		} else {
+			v, err = strconv.ParseUint(ref, 10, 32)
+		}
+		switch {
+		case err != nil || v > unicode.MaxRune:
+			out = utf8.AppendRune(out, utf8.RuneError)
+		case v >= 0xdc80 && v <= 0xdcff:
+			out = append(out, byte(v-0xdc00))
+		default:
+			out = utf8.AppendRune(out, rune(v))
+		}
+	}
+	return out, nil
+}
+
+// EncodeUnicode writes every character as a \uXXXX escape (surrogate pairs
+// above the BMP, as JavaScript and JSON do) and invalid UTF-8 bytes as \xHH
+func EncodeUnicode(data []byte) string {
+	var b strings.Builder
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		switch {
+		case r == utf8.RuneError && size <= 1:
+			fmt.Fprintf(&b, `\x%02x`, data[0])
+		case r > 0xffff:
+			hi, lo := utf16.EncodeRune(r)
+			fmt.Fprintf(&b, `\u%04x\u%04x`, hi, lo)
+		default:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		}
+		data = data[size:]
+	}
+	return b.String()
+}
+
+// DecodeUnicode resolves \uXXXX (joining surrogate pairs) and \xHH escapes,
+// leaving any other text as it is
+func DecodeUnicode(data string) ([]byte, error) {
+	var out []byte
+	for i := 0; i < len(data); {
+		loc := unicodeEscape.FindStringIndex(data[i:])
+		if loc == nil {
+			out = append(out, data[i:]...)
+			break
+		}
+		out = append(out, data[i:i+loc[0]]...)
+		esc := data[i+loc[0] : i+loc[1]]
+		i += loc[1]
+
+		v, _ := strconv.ParseUint(esc[2:], 16, 32)
+		if esc[1] == 'x' {
+			out = append(out, byte(v))
+			continue
+		}
+
+		r := rune(v)
+		if utf16.IsSurrogate(r) {
+			if next := unicodeEscape.FindStringIndex(data[i:]); next != nil && next[0] == 0 && data[i+1] == 'u' {
+				lo, _ := strconv.ParseUint(data[i+2:i+next[1]], 16, 32)
+				if joined := utf16.DecodeRune(r, rune(lo)); joined != utf8.RuneError {
+					r = joined
+					i += next[1]
+				}
+			}
+		}
+		out = utf8.AppendRune(out, r)
+	}
+	return out, nil
+}
+
+// EncodeQuotedPrintable encodes data as MIME quoted-printable. Line breaks
+// in the data are escaped too, so the output round-trips byte for byte.
+func EncodeQuotedPrintable(data []byte) string {
+	var buf bytes.Buffer
+	w := quotedprintable.NewWriter(&buf)
+	w.Binary = true
+	w.Write(data)
+	w.Close()
+	return strings.ReplaceAll(buf.String(), "\r\n", "\n")
+}
+
+// DecodeQuotedPrintable decodes MIME quoted-printable text
+func DecodeQuotedPrintable(data string) ([]byte, error) {
+	return io.ReadAll(quotedprintable.NewReader(strings.NewReader(data)))
+}
+
+// hasEscapes returns a syntax score that is zero unless data contains a
+// match for escape, so escape-free text isn't detected as a web encoding
+func hasEscapes(escape *regexp.Regexp, score float64) func(string) float64 {
+	return func(data string) float64 {
+		if !escape.MatchString(data) {
+			return 0
+		}
+		return score
+	}
+}
@@ -0,0 +1,101 @@
+package crypto
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+var webEncodings = []struct {
+	name   string
+	encode func([]byte) string
+	decode func(string) ([]byte, error)
+}{
+	{"url", EncodeURL, DecodeURL},
+	{"html", EncodeHTML, DecodeHTML},
+	{"unicode", EncodeUnicode, DecodeUnicode},
+	{"qp", EncodeQuotedPrintable, DecodeQuotedPrintable},
+}
+
+// Every web encoding round-trips text and arbitrary bytes, such as
+// ciphertext, unchanged
+func TestWebRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := make([]byte, 1000)
+	rng.Read(random)
+	every := make([]byte, 256)
+	for i := range every {
+		every[i] = byte(i)
+	}
+	inputs := map[string][]byte{
+		"ascii":          []byte(`Tom & Jerry <b>"hi"</b> it's 100% + 5 = done?`),
+		"utf-8":          []byte("héllo wörld ☃ 😀 \u0080\u009f"),
+		"line breaks":    []byte("line one\r\nline two\n  indented \t\n"),
+		"escape-like":    []byte(`A \x41 %41 &#65; &amp; =41`),
+		"every byte":     every,
+		"random":         random,
+		"invalid utf-8":  {0xff, 'a', 0xc3, 0xed, 0xa0, 0x80, 0xf0, 0x9f},
+		"lone surrogate": []byte("&#xDC80;"),
+	}
+	for _, enc := range webEncodings {
+		for name, data := range inputs {
+			encoded := enc.encode(data)
+			got, err := enc.decode(encoded)
+			if err != nil {
+				t.Errorf("%s, %s: %v", enc.name, name, err)
+				continue
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("%s, %s: got %q, want %q (encoded %q)", enc.name, name, got, data, encoded)
+			}
+		}
+	}
+}
+
+func TestWebKnownEncodings(t *testing.T) {
+	tests := []struct {
+		name   string
+		encode func([]byte) string
+		data   string
+		want   string
+	}{
+		{"url", EncodeURL, "a b&c/é", "a%20b%26c%2F%C3%A9"},
+		{"html", EncodeHTML, `<a href="x">é</a>`, "&lt;a href=&quot;x&quot;&gt;&#xE9;&lt;/a&gt;"},
+		{"html invalid byte", EncodeHTML, "a\xffb", "a&#xDCFF;b"},
+		{"unicode", EncodeUnicode, "A😀\xff", `\u0041\ud83d\ude00\xff`},
+		{"qp", EncodeQuotedPrintable, "café=1", "caf=C3=A9=3D1"},
+	}
+	for _, tt := range tests {
+		if got := tt.encode([]byte(tt.data)); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWebDecodeForeign(t *testing.T) {
+	// Input written by other tools, not produced by the encoders here
+	tests := []struct {
+		name   string
+		decode func(string) ([]byte, error)
+		data   string
+		want   string
+	}{
+		{"url plus", DecodeURL, "a+b%21", "a b!"},
+		{"html named", DecodeHTML, "caf&eacute; &lt;3 &copy;", "café <3 ©"},
+		{"html decimal", DecodeHTML, "&#72;&#105;", "Hi"},
+		{"html C1 control", DecodeHTML, "&#x80;", "\u0080"},
+		{"html out of range", DecodeHTML, "&#x110000;", "�"},
+		{"unicode mixed", DecodeUnicode, `say hi\x21`, "say hi!"},
+		{"qp soft break", DecodeQuotedPrintable, "long=\nline", "longline"},
+	}
+	for _, tt := range tests {
+		got, err := tt.decode(tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
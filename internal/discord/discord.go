@@ -2,11 +2,14 @@ package discord
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
 
 	"github.com/bwmarrin/discordgo"
+
+	"doc0x1/text2babe/internal/stego"
 )
 
 type Client struct {
@@ -63,7 +66,7 @@ func (c *Client) GetDMID() string {
 
 func (c *Client) Connect() error {
 	if !c.enabled {
-		return fmt.Errorf("discord not configured")
+		return ErrNotConfigured
 	}
 	
 	var err error
@@ -84,7 +87,7 @@ func (c *Client) Connect() error {
 // FetchLastMessage retrieves the last message from the DM channel
 func (c *Client) FetchLastMessage() (*discordgo.Message, error) {
 	if !c.enabled {
-		return nil, fmt.Errorf("discord not configured")
+		return nil, ErrNotConfigured
 	}
 	
 	if c.session == nil {
@@ -96,15 +99,11 @@ func (c *Client) FetchLastMessage() (*discordgo.Message, error) {
 	// Fetch the last message (limit 1)
 	messages, err := c.session.ChannelMessages(c.dmID, 1, "", "", "")
 	if err != nil {
-		if discordErr, ok := err.(*discordgo.RESTError); ok {
-			switch discordErr.Message.Code {
-			case 10003: // Unknown Channel
-				return nil, fmt.Errorf("invalid DISCORD_DM_ID - channel not found")
-			case 50001: // Missing Access
-				return nil, fmt.Errorf("cannot access DM channel - check permissions")
-			default:
-				return nil, fmt.Errorf("Discord API error %d: %s", discordErr.Message.Code, discordErr.Message.Message)
-			}
+		if apiErr := apiError(err, map[int]string{
+			CodeUnknownChannel: "invalid DISCORD_DM_ID - channel not found",
+			CodeMissingAccess:  "cannot access DM channel - check permissions",
+		}); apiErr != nil {
+			return nil, apiErr
 		}
 		return nil, fmt.Errorf("failed to fetch messages: %w", err)
 	}
@@ -119,9 +118,14 @@ func (c *Client) FetchLastMessage() (*discordgo.Message, error) {
 // ValidateText2BabeMessage checks if a message is from text2babe and extracts the encrypted data
 func (c *Client) ValidateText2BabeMessage(message *discordgo.Message) (string, string, error) {
 	content := message.Content
+
+	// Steganographic messages are plain cover text with a hidden payload
+	if stego.ContainsHidden(content) {
+		return content, "encrypt", nil
+	}
 	
 	// Check if the message matches text2babe format: 🔒/**🔓 **Text2Babe encrypt/decrypt**
-	text2babePattern := regexp.MustCompile(`^(🔒|🔓)\s\*\*Text2Babe\s(encrypt|decrypt)\*\*\s*\n\x60\x60\x60\s*(.*?)\s*\n\x60\x60\x60$`)
+	text2babePattern := regexp.MustCompile(`(?s)^(🔒|🔓)\s\*\*Text2Babe\s(encrypt|decrypt)\*\*\s*\n\x60\x60\x60\s*(.*?)\s*\n\x60\x60\x60$`)
 	
 	matches := text2babePattern.FindStringSubmatch(strings.TrimSpace(content))
 	if len(matches) != 4 {
@@ -156,9 +160,16 @@ func (c *Client) FetchAndValidateLastMessage() (string, string, error) {
 	return c.ValidateText2BabeMessage(message)
 }
 
+// sendHints explains the errors Discord returns when posting to the DM
+var sendHints = map[int]string{
+	CodeMissingAccess:    "bot cannot send DMs to this user - ensure you share a server and user allows DMs from server members",
+	CodeCannotSendToUser: "user has DMs disabled or bot is blocked",
+	CodeUnknownChannel:   "invalid channel/user ID - check DISCORD_DM_ID",
+}
+
 func (c *Client) SendMessage(content string) error {
 	if !c.enabled {
-		return fmt.Errorf("discord not configured")
+		return ErrNotConfigured
 	}
 	
 	if c.session == nil {
@@ -170,17 +181,8 @@ func (c *Client) SendMessage(content string) error {
 	_, err := c.session.ChannelMessageSend(c.dmID, content)
 	if err != nil {
 		// Provide more helpful error messages for common issues
-		if discordErr, ok := err.(*discordgo.RESTError); ok {
-			switch discordErr.Message.Code {
-			case 50001: // Missing Access
-				return fmt.Errorf("bot cannot send DMs to this user - ensure you share a server and user allows DMs from server members")
-			case 50007: // Cannot send messages to this user
-				return fmt.Errorf("user has DMs disabled or bot is blocked")
-			case 10003: // Unknown Channel
-				return fmt.Errorf("invalid channel/user ID - check DISCORD_DM_ID")
-			default:
-				return fmt.Errorf("Discord API error %d: %s", discordErr.Message.Code, discordErr.Message.Message)
-			}
+		if apiErr := apiError(err, sendHints); apiErr != nil {
+			return apiErr
 		}
 		return fmt.Errorf("failed to send Discord message: %w", err)
 	}
@@ -188,9 +190,36 @@ func (c *Client) SendMessage(content string) error {
 	return nil
 }
 
+// SendFile uploads a file attachment to the DM channel
+func (c *Client) SendFile(name string, r io.Reader) error {
+	if !c.enabled {
+		return ErrNotConfigured
+	}
+	
+	if c.session == nil {
+		if err := c.Connect(); err != nil {
+			return err
+		}
+	}
+	
+	if _, err := c.session.ChannelFileSend(c.dmID, name, r); err != nil {
+		if apiErr := apiError(err, sendHints); apiErr != nil {
+			return apiErr
+		}
+		return fmt.Errorf("failed to send Discord attachment: %w", err)
+	}
+	
+	return nil
+}
+
 func (c *Client) SendEncryptedData(data, mode string) error {
 	if !c.enabled {
-		return fmt.Errorf("discord not configured")
+		return ErrNotConfigured
+	}
+	
+	// Cover text is sent as-is so it reads like a normal chat line
+	if stego.ContainsHidden(data) {
+		return c.SendMessage(data)
 	}
 	
 	emoji := "🔒"
@@ -213,7 +242,7 @@ func (c *Client) Disconnect() {
 // TestConnection attempts to validate the bot setup
 func (c *Client) TestConnection() error {
 	if !c.enabled {
-		return fmt.Errorf("discord not configured")
+		return ErrNotConfigured
 	}
 	
 	if c.session == nil {
@@ -225,15 +254,11 @@ func (c *Client) TestConnection() error {
 	// Try to get channel info to validate the DM channel
 	_, err := c.session.Channel(c.dmID)
 	if err != nil {
-		if discordErr, ok := err.(*discordgo.RESTError); ok {
-			switch discordErr.Message.Code {
-			case 10003: // Unknown Channel
-				return fmt.Errorf("invalid DISCORD_DM_ID - channel/user not found")
-			case 50001: // Missing Access
-				return fmt.Errorf("bot cannot access this channel - check permissions")
-			default:
-				return fmt.Errorf("Discord API error %d: %s", discordErr.Message.Code, discordErr.Message.Message)
-			}
+		if apiErr := apiError(err, map[int]string{
+			CodeUnknownChannel: "invalid DISCORD_DM_ID - channel/user not found",
+			CodeMissingAccess:  "bot cannot access this channel - check permissions",
+		}); apiErr != nil {
+			return apiErr
 		}
 		return fmt.Errorf("failed to validate Discord channel: %w", err)
 	}
@@ -0,0 +1,58 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ErrNotConfigured is returned when DISCORD_USER_TOKEN or DISCORD_DM_ID is missing
+var ErrNotConfigured = errors.New("discord not configured (missing DISCORD_USER_TOKEN or DISCORD_DM_ID)")
+
+// Discord JSON error codes that get a specific explanation
+const (
+	CodeUnknownChannel   = 10003
+	CodeMissingAccess    = 50001
+	CodeCannotSendToUser = 50007
+)
+
+// DiscordAPIError is a failed Discord REST call
+type DiscordAPIError struct {
+	Code    int    // Discord JSON error code, 0 if the response had none
+	Status  int    // HTTP status code
+	Message string // Discord's own message
+	Hint    string // what the code means for this call, if known
+	Err     error
+}
+
+func (e *DiscordAPIError) Error() string {
+	if e.Hint != "" {
+		return e.Hint
+	}
+	if e.Code != 0 {
+		return fmt.Sprintf("Discord API error %d: %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("Discord API error: HTTP %d", e.Status)
+}
+
+func (e *DiscordAPIError) Unwrap() error { return e.Err }
+
+// apiError converts a discordgo REST error, explaining known codes with
+// hints. Other errors are returned as nil.
+func apiError(err error, hints map[int]string) *DiscordAPIError {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) {
+		return nil
+	}
+	apiErr := &DiscordAPIError{Err: err}
+	if restErr.Response != nil {
+		apiErr.Status = restErr.Response.StatusCode
+	}
+	if restErr.Message != nil {
+		apiErr.Code = restErr.Message.Code
+		apiErr.Message = restErr.Message.Message
+		apiErr.Hint = hints[apiErr.Code]
+	}
+	return apiErr
+}
@@ -0,0 +1,192 @@
+package paper
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+	"time"
+
+	"doc0x1/text2babe/internal/qr"
+)
+
+// A paper backup carries the key three ways: a QR code of the key string,
+// a retypeable base32 block and the fingerprint to confirm the result.
+//
+// The base32 block encodes the key followed by its CRC-32. Each line holds
+// four groups of four characters and ends with two check characters that
+// cover the line number and content, so a typo is pinned to its line.
+
+// KeyPrefix marks a key string, as stored in the QR code and key files
+const KeyPrefix = "t2b-key:"
+
+const (
+	groupSize     = 4
+	groupsPerLine = 4
+	lineChars     = groupSize * groupsPerLine
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// KeyString formats a key as "t2b-key:<hex>"
+func KeyString(key []byte) string {
+	return KeyPrefix + hex.EncodeToString(key)
+}
+
+// ParseKeyString reads a key written by KeyString
+func ParseKeyString(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, KeyPrefix) {
+		return nil, fmt.Errorf("key string must start with %q", KeyPrefix)
+	}
+	key, err := hex.DecodeString(strings.TrimPrefix(s, KeyPrefix))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("key string must hold 64 hex digits")
+	}
+	return key, nil
+}
+
+// Lines returns the numbered, checksummed base32 lines for key
+func Lines(key []byte) []string {
+	payload := binary.BigEndian.AppendUint32(append([]byte(nil), key...), crc32.ChecksumIEEE(key))
+	text := encoding.EncodeToString(payload)
+
+	var lines []string
+	for n := 1; len(text) > 0; n++ {
+		chunk := text[:min(lineChars, len(text))]
+		text = text[len(chunk):]
+
+		var groups []string
+		for i := 0; i < len(chunk); i += groupSize {
+			groups = append(groups, chunk[i:min(i+groupSize, len(chunk))])
+		}
+		lines = append(lines, fmt.Sprintf("%d: %-19s  %s", n, strings.Join(groups, " "), lineCheck(n, chunk)))
+	}
+	return lines
+}
+
+// lineCheck is two base32 characters (10 bits) of CRC-32 over the line
+// number and its characters
+func lineCheck(n int, chunk string) string {
+	sum := crc32.ChecksumIEEE([]byte(strconv.Itoa(n) + ":" + chunk))
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
+	return string([]byte{alphabet[sum>>5&31], alphabet[sum&31]})
+}
+
+// LineError reports a typed line whose check characters do not match
+type LineError struct {
+	Line int
+	Text string
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d has a typo (check characters do not match): %s", e.Line, e.Text)
+}
+
+// normalize upper-cases base32 text and maps digits commonly confused with
+// letters that base32 does not use
+func normalize(s string) string {
+	return strings.NewReplacer("0", "O", "1", "I", "8", "B").Replace(strings.ToUpper(s))
+}
+
+// ParseLines rebuilds a key from typed backup lines. Blank lines are
+// skipped; line numbers may be left out, in which case position is used.
+// A key string ("t2b-key:...") from the QR code is accepted as well.
+func ParseLines(input []string) ([]byte, error) {
+	var text strings.Builder
+	n := 0
+	for _, raw := range input {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, KeyPrefix) {
+			return ParseKeyString(line)
+		}
+		n++
+
+		number := n
+		if label, rest, ok := strings.Cut(line, ":"); ok {
+			parsed, err := strconv.Atoi(strings.TrimSpace(label))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid line number %q", n, label)
+			}
+			number, line = parsed, rest
+		}
+		if number != n {
+			return nil, fmt.Errorf("expected line %d but found line %d; lines are missing or out of order", n, number)
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: missing the two check characters at the end", n)
+		}
+		check := normalize(fields[len(fields)-1])
+		chunk := normalize(strings.Join(fields[:len(fields)-1], ""))
+		if lineCheck(number, chunk) != check {
+			return nil, &LineError{Line: number, Text: strings.TrimSpace(raw)}
+		}
+		text.WriteString(chunk)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("no backup lines given")
+	}
+
+	payload, err := encoding.DecodeString(text.String())
+	if err != nil || len(payload) != 36 {
+		return nil, fmt.Errorf("backup is incomplete: expected %d lines", len(Lines(make([]byte, 32))))
+	}
+	key, sum := payload[:32], binary.BigEndian.Uint32(payload[32:])
+	if crc32.ChecksumIEEE(key) != sum {
+		return nil, fmt.Errorf("every line checks out but the key checksum does not; compare the lines with the sheet again")
+	}
+	return key, nil
+}
+
+// Sheet holds everything printed on a backup
+type Sheet struct {
+	Key         []byte
+	Fingerprint string
+	Created     time.Time
+}
+
+// Instructions explains how to recover the key from the sheet
+var Instructions = []string{
+	"Keep this sheet somewhere safe. Anyone holding it can read your messages.",
+	"To restore, run 'key restore' and type the numbered lines above, or scan",
+	"the QR code and paste the t2b-key: text it contains.",
+	"Letters are case-insensitive and spaces are ignored. Each line ends with",
+	"two check characters, so a mistyped line is reported by number.",
+	"Confirm that the restored key shows the fingerprint printed on this sheet.",
+}
+
+func (s *Sheet) qr() (*qr.Code, error) {
+	return qr.Encode([]byte(KeyString(s.Key)), qr.MaxVersion)
+}
+
+// Text renders the sheet as plain text with a block-character QR code
+func (s *Sheet) Text() (string, error) {
+	code, err := s.qr()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("TEXT2BABE KEY BACKUP\n")
+	b.WriteString("====================\n\n")
+	fmt.Fprintf(&b, "Fingerprint: %s\n", s.Fingerprint)
+	fmt.Fprintf(&b, "Created:     %s\n\n", s.Created.Format("2006-01-02"))
+	b.WriteString(code.Text())
+	b.WriteString("\nKey (base32, line number and check characters):\n\n")
+	for _, line := range Lines(s.Key) {
+		b.WriteString("    " + line + "\n")
+	}
+	b.WriteString("\nRecovery:\n")
+	for _, line := range Instructions {
+		b.WriteString("  " + line + "\n")
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,186 @@
+package paper
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testKey(seed int64) []byte {
+	key := make([]byte, 32)
+	rand.New(rand.NewSource(seed)).Read(key)
+	return key
+}
+
+func TestKeyStringRoundTrip(t *testing.T) {
+	key := testKey(1)
+	s := KeyString(key)
+	if !strings.HasPrefix(s, KeyPrefix) {
+		t.Fatalf("%q lacks the %q prefix", s, KeyPrefix)
+	}
+	for _, text := range []string{s, "  " + s + "\n", KeyPrefix + strings.ToUpper(s[len(KeyPrefix):])} {
+		got, err := ParseKeyString(text)
+		if err != nil {
+			t.Fatalf("%q: %v", text, err)
+		}
+		if !bytes.Equal(got, key) {
+			t.Fatalf("%q: got %x, want %x", text, got, key)
+		}
+	}
+
+	for _, bad := range []string{"", s[len(KeyPrefix):], s[:len(s)-2], s + "00", KeyPrefix + "zz"} {
+		if _, err := ParseKeyString(bad); err == nil {
+			t.Errorf("ParseKeyString(%q) accepted a bad key string", bad)
+		}
+	}
+}
+
+func TestLinesRoundTrip(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		key := testKey(seed)
+		lines := Lines(key)
+		got, err := ParseLines(lines)
+		if err != nil {
+			t.Fatalf("key %x: %v\n%s", key, err, strings.Join(lines, "\n"))
+		}
+		if !bytes.Equal(got, key) {
+			t.Fatalf("got %x, want %x", got, key)
+		}
+	}
+}
+
+// Typed lines are accepted in the forms people actually type them
+func TestParseLinesRetyped(t *testing.T) {
+	key := testKey(2)
+	lines := Lines(key)
+	retype := func(f func(int, string) string) []string {
+		out := []string{""}
+		for i, line := range lines {
+			out = append(out, f(i, line), "")
+		}
+		return out
+	}
+	tests := []struct {
+		name  string
+		input []string
+	}{
+		{"lower case", retype(func(_ int, l string) string { return strings.ToLower(l) })},
+		{"groups run together", retype(func(_ int, l string) string {
+			data, check := l[:len(l)-2], l[len(l)-2:]
+			return strings.ReplaceAll(data, " ", "") + " " + check
+		})},
+		{"no line numbers", retype(func(_ int, l string) string {
+			_, rest, _ := strings.Cut(l, ":")
+			return rest
+		})},
+		{"O and I typed as digits", retype(func(_ int, l string) string {
+			number, rest, _ := strings.Cut(l, ":")
+			return number + ":" + strings.NewReplacer("O", "0", "I", "1").Replace(rest)
+		})},
+		{"QR code text", []string{KeyString(key)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLines(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, key) {
+				t.Errorf("got %x, want %x", got, key)
+			}
+		})
+	}
+}
+
+func TestParseLinesTypo(t *testing.T) {
+	lines := Lines(testKey(3))
+	for i := range lines {
+		typo := append([]string(nil), lines...)
+		// Swap one character of the line's data for another base32 letter
+		b := []byte(typo[i])
+		pos := strings.Index(typo[i], ":") + 3
+		if b[pos] == 'A' {
+			b[pos] = 'B'
+		} else {
+			b[pos] = 'A'
+		}
+		typo[i] = string(b)
+
+		_, err := ParseLines(typo)
+		var lineErr *LineError
+		if !errors.As(err, &lineErr) {
+			t.Fatalf("typo in line %d: got %v, want a LineError", i+1, err)
+		}
+		if lineErr.Line != i+1 {
+			t.Errorf("typo in line %d reported on line %d", i+1, lineErr.Line)
+		}
+	}
+}
+
+func TestParseLinesRejects(t *testing.T) {
+	lines := Lines(testKey(4))
+	tests := []struct {
+		name  string
+		input []string
+		want  string // part of the error message
+	}{
+		{"nothing typed", []string{"", "  "}, "no backup lines"},
+		{"missing line", append(lines[:1:1], lines[2:]...), "lines are missing or out of order"},
+		{"swapped lines", append([]string{lines[1], lines[0]}, lines[2:]...), "lines are missing or out of order"},
+		{"last line missing", lines[:len(lines)-1], "incomplete"},
+		{"bad line number", []string{"one: ABCD EFGH  XY"}, "invalid line number"},
+		{"no check characters", []string{"1: ABCDEFGH"}, "missing the two check characters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLines(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want an error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSheet(t *testing.T) {
+	sheet := &Sheet{
+		Key:         testKey(5),
+		Fingerprint: "ab12 cd34 ef56 7890",
+		Created:     time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC),
+	}
+	text, err := sheet.Text()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Fingerprint: ab12 cd34 ef56 7890", "Created:     2024-03-09", "█"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("sheet text lacks %q", want)
+		}
+	}
+
+	// The lines printed on the sheet restore the key
+	_, printed, _ := strings.Cut(text, "check characters):")
+	printed, _, _ = strings.Cut(printed, "Recovery:")
+	got, err := ParseLines(strings.Split(printed, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, sheet.Key) {
+		t.Errorf("sheet lines restore %x, want %x", got, sheet.Key)
+	}
+
+	pdf, err := sheet.PDF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Error("PDF lacks its header or trailer")
+	}
+	for _, line := range Lines(sheet.Key) {
+		if !bytes.Contains(pdf, []byte(line)) {
+			t.Errorf("PDF lacks the backup line %q", line)
+		}
+	}
+}
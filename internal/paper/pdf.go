@@ -0,0 +1,95 @@
+package paper
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// PDF renders the sheet as a single A4 page. The file is written by hand:
+// standard fonts only, the QR code drawn as filled squares.
+func (s *Sheet) PDF() ([]byte, error) {
+	code, err := s.qr()
+	if err != nil {
+		return nil, err
+	}
+
+	var c strings.Builder
+	text := func(font string, size, x, y float64, str string) {
+		fmt.Fprintf(&c, "BT /%s %g Tf %g %g Td (%s) Tj ET\n", font, size, x, y, pdfEscape(str))
+	}
+
+	const left, pageHeight = 56.0, 842.0
+	y := pageHeight - 72
+	text("F2", 20, left, y, "text2babe key backup")
+	y -= 28
+	text("F1", 11, left, y, "Fingerprint: "+s.Fingerprint)
+	y -= 16
+	text("F1", 11, left, y, "Created: "+s.Created.Format("2006-01-02"))
+
+	// QR code; the white page margin serves as its quiet zone
+	const module = 4.0
+	y -= 24
+	top := y
+	for my := 0; my < code.Size; my++ {
+		for mx := 0; mx < code.Size; mx++ {
+			if code.Dark(mx, my) {
+				fmt.Fprintf(&c, "%g %g %g %g re\n", left+float64(mx)*module, top-float64(my+1)*module, module, module)
+			}
+		}
+	}
+	c.WriteString("f\n")
+	y = top - float64(code.Size)*module - 36
+
+	text("F2", 12, left, y, "Key (base32, line number and check characters)")
+	y -= 24
+	for _, line := range Lines(s.Key) {
+		text("F3", 14, left+12, y, line)
+		y -= 20
+	}
+
+	y -= 16
+	text("F2", 12, left, y, "Recovery")
+	y -= 18
+	for _, line := range Instructions {
+		text("F1", 10, left, y, line)
+		y -= 14
+	}
+
+	return buildPDF(c.String()), nil
+}
+
+func pdfEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+}
+
+// buildPDF wraps one page's content stream in a minimal PDF 1.4 document
+func buildPDF(content string) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] " +
+			"/Resources << /Font << /F1 4 0 R /F2 5 0 R /F3 6 0 R >> >> /Contents 7 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content),
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n", len(objects)+1)
+	b.WriteString("0000000000 65535 f \n")
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
@@ -0,0 +1,416 @@
+package qr
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"doc0x1/text2babe/internal/rs"
+)
+
+// Format information for level M with masks 0-7 (ISO/IEC 18004 table C.1)
+var formatM = [8]int{0x5412, 0x5125, 0x5e7c, 0x5b4b, 0x45f9, 0x40ce, 0x4f97, 0x4aa0}
+
+// decode reads a byte-mode, level M symbol back from its modules, the way a
+// scanner would once it has located the grid
+func decode(grid [][]bool) ([]byte, error) {
+	size := len(grid)
+	version := (size - 17) / 4
+	if size < 21 || (size-17)%4 != 0 {
+		return nil, fmt.Errorf("%d modules is not a QR code size", size)
+	}
+	dark := func(x, y int) int {
+		if grid[y][x] {
+			return 1
+		}
+		return 0
+	}
+
+	// Both copies of the format information must name the same mask
+	format, format2 := 0, 0
+	for i := 0; i <= 5; i++ {
+		format |= dark(8, i) << i
+	}
+	format |= dark(8, 7)<<6 | dark(8, 8)<<7 | dark(7, 8)<<8
+	for i := 9; i < 15; i++ {
+		format |= dark(14-i, 8) << i
+	}
+	for i := 0; i < 8; i++ {
+		format2 |= dark(size-1-i, 8) << i
+	}
+	for i := 8; i < 15; i++ {
+		format2 |= dark(8, size-15+i) << i
+	}
+	if format != format2 {
+		return nil, fmt.Errorf("format copies differ: %015b and %015b", format, format2)
+	}
+	mask := -1
+	for m, f := range formatM {
+		if f == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		return nil, fmt.Errorf("format %015b is not level M", format)
+	}
+
+	if version >= 7 {
+		bits := 0
+		for i := 0; i < 18; i++ {
+			bits |= dark(size-11+i%3, i/3) << i
+		}
+		if bits>>12 != version {
+			return nil, fmt.Errorf("version block says %d, size says %d", bits>>12, version)
+		}
+	}
+
+	reserved := &Code{Version: version, Size: size, modules: make([][]bool, size), function: make([][]bool, size)}
+	for i := range reserved.modules {
+		reserved.modules[i] = make([]bool, size)
+		reserved.function[i] = make([]bool, size)
+	}
+	reserved.drawFunctionPatterns()
+
+	masks := [8]func(i, j int) bool{
+		func(i, j int) bool { return (i+j)%2 == 0 },
+		func(i, j int) bool { return i%2 == 0 },
+		func(i, j int) bool { return j%3 == 0 },
+		func(i, j int) bool { return (i+j)%3 == 0 },
+		func(i, j int) bool { return (i/2+j/3)%2 == 0 },
+		func(i, j int) bool { return i*j%2+i*j%3 == 0 },
+		func(i, j int) bool { return (i*j%2+i*j%3)%2 == 0 },
+		func(i, j int) bool { return ((i+j)%2+i*j%3)%2 == 0 },
+	}
+
+	// Two-column strips from the right, alternately upwards and downwards,
+	// stepping over the vertical timing pattern
+	var bits []bool
+	for strip, right := 0, size-1; right > 0; strip, right = strip+1, right-2 {
+		if right == 6 {
+			right--
+		}
+		for step := 0; step < size; step++ {
+			y := step
+			if strip%2 == 0 {
+				y = size - 1 - step
+			}
+			for x := right; x >= right-1; x-- {
+				if !reserved.function[y][x] {
+					bits = append(bits, grid[y][x] != masks[mask](y, x))
+				}
+			}
+		}
+	}
+	codewords := make([]byte, len(bits)/8) // leftover remainder bits are ignored
+	for i := range codewords {
+		for _, bit := range bits[i*8 : i*8+8] {
+			codewords[i] <<= 1
+			if bit {
+				codewords[i] |= 1
+			}
+		}
+	}
+
+	// De-interleave: data codewords column by column (the longer blocks
+	// come last and have one extra), then the parity the same way
+	numBlocks, eccLen := eccBlocks[version], eccPerBlock[version]
+	numShort := numBlocks - len(codewords)%numBlocks
+	shortData := len(codewords)/numBlocks - eccLen
+	blocks := make([][]byte, numBlocks)
+	next := 0
+	for col := 0; col <= shortData; col++ {
+		for i := range blocks {
+			if col < shortData || i >= numShort {
+				blocks[i] = append(blocks[i], codewords[next])
+				next++
+			}
+		}
+	}
+	for col := 0; col < eccLen; col++ {
+		for i := range blocks {
+			blocks[i] = append(blocks[i], codewords[next])
+			next++
+		}
+	}
+	var data []byte
+	for i, block := range blocks {
+		payload, parity := block[:len(block)-eccLen], block[len(block)-eccLen:]
+		if !bytes.Equal(rs.Encode(payload, eccLen), parity) {
+			return nil, fmt.Errorf("block %d: error correction codewords do not match", i)
+		}
+		data = append(data, payload...)
+	}
+
+	pos := 0
+	read := func(n int) int {
+		v := 0
+		for ; n > 0; n-- {
+			v = v<<1 | int(data[pos/8]>>(7-pos%8)&1)
+			pos++
+		}
+		return v
+	}
+	if m := read(4); m != 0x4 {
+		return nil, fmt.Errorf("mode %04b is not byte mode", m)
+	}
+	countLen := 8
+	if version >= 10 {
+		countLen = 16
+	}
+	count := read(countLen)
+	if pos+count*8 > len(data)*8 {
+		return nil, fmt.Errorf("length %d exceeds the symbol", count)
+	}
+	out := make([]byte, count)
+	for i := range out {
+		out[i] = byte(read(8))
+	}
+	return out, nil
+}
+
+// modules copies the code's modules through the exported API
+func modules(c *Code) [][]bool {
+	grid := make([][]bool, c.Size)
+	for y := range grid {
+		grid[y] = make([]bool, c.Size)
+		for x := range grid[y] {
+			grid[y][x] = c.Dark(x, y)
+		}
+	}
+	return grid
+}
+
+func TestCapacity(t *testing.T) {
+	// Byte-mode capacities for level M from the QR specification
+	want := map[int]int{1: 14, 2: 26, 6: 106, 7: 122, 9: 180, 10: 213, 20: 666, 40: 2331}
+	for version, capacity := range want {
+		if got := Capacity(version); got != capacity {
+			t.Errorf("Capacity(%d) = %d, want %d", version, got, capacity)
+		}
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var sizes []int
+	for _, v := range []int{1, 2, 5, 7, 9, 10, 14, 25, 40} {
+		sizes = append(sizes, Capacity(v))
+		if v < MaxVersion {
+			sizes = append(sizes, Capacity(v)+1)
+		}
+	}
+	sizes = append(sizes, 0, 1)
+
+	for _, size := range sizes {
+		t.Run(strconv.Itoa(size), func(t *testing.T) {
+			data := make([]byte, size)
+			rng.Read(data)
+			code, err := Encode(data, MaxVersion)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code.Size != code.Version*4+17 {
+				t.Errorf("version %d is %d modules wide", code.Version, code.Size)
+			}
+			if size > Capacity(code.Version) || (code.Version > MinVersion && size <= Capacity(code.Version-1)) {
+				t.Errorf("%d bytes placed in version %d", size, code.Version)
+			}
+
+			got, err := decode(modules(code))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("got %x, want %x", got, data)
+			}
+		})
+	}
+}
+
+func TestImageRoundTrip(t *testing.T) {
+	data := []byte("https://example.com/a/fairly/long/path?with=query&and=more")
+	code, err := Encode(data, MaxVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, scale := range []int{1, 4, 7} {
+		var buf bytes.Buffer
+		if err := code.WritePNG(&buf, scale); err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := (code.Size + 2*quietZone) * scale; img.Bounds() != image.Rect(0, 0, want, want) {
+			t.Fatalf("scale %d: image is %v, want %dx%d", scale, img.Bounds(), want, want)
+		}
+
+		// Sample the centre of each module, including the quiet zone
+		sample := func(x, y int) bool {
+			r, _, _, _ := img.At((x+quietZone)*scale+scale/2, (y+quietZone)*scale+scale/2).RGBA()
+			return r < 0x8000
+		}
+		for i := -quietZone; i < code.Size+quietZone; i++ {
+			for _, p := range [][2]int{{i, -1}, {i, code.Size}, {-1, i}, {code.Size, i}} {
+				if sample(p[0], p[1]) {
+					t.Fatalf("scale %d: quiet zone is dark at %v", scale, p)
+				}
+			}
+		}
+		grid := make([][]bool, code.Size)
+		for y := range grid {
+			grid[y] = make([]bool, code.Size)
+			for x := range grid[y] {
+				grid[y][x] = sample(x, y)
+			}
+		}
+		got, err := decode(grid)
+		if err != nil {
+			t.Fatalf("scale %d: %v", scale, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("scale %d: got %q, want %q", scale, got, data)
+		}
+	}
+}
+
+// fromHalfBlocks reads modules back from Text or Terminal output
+func fromHalfBlocks(t *testing.T, text string, size int, invert bool) [][]bool {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	width := size + 2*quietZone
+	if want := (width + 1) / 2; len(lines) != want {
+		t.Fatalf("%d lines, want %d", len(lines), want)
+	}
+	full := make([][]bool, 0, width+1)
+	for _, line := range lines {
+		top, bottom := make([]bool, 0, width), make([]bool, 0, width)
+		for _, r := range line {
+			var tp, bt bool
+			switch r {
+			case '█':
+				tp, bt = true, true
+			case '▀':
+				tp = true
+			case '▄':
+				bt = true
+			case ' ':
+			default:
+				t.Fatalf("unexpected character %q", r)
+			}
+			top, bottom = append(top, tp != invert), append(bottom, bt != invert)
+		}
+		if len(top) != width {
+			t.Fatalf("line is %d modules wide, want %d", len(top), width)
+		}
+		full = append(full, top, bottom)
+	}
+
+	grid := make([][]bool, size)
+	for y := range grid {
+		grid[y] = full[y+quietZone][quietZone : quietZone+size]
+	}
+	for y, row := range full[:width] {
+		for x, dark := range row {
+			inside := y >= quietZone && y < quietZone+size && x >= quietZone && x < quietZone+size
+			if dark && !inside {
+				t.Fatalf("quiet zone is dark at (%d, %d)", x-quietZone, y-quietZone)
+			}
+		}
+	}
+	return grid
+}
+
+func TestHalfBlocksRoundTrip(t *testing.T) {
+	for _, size := range []int{5, 100, 400} {
+		data := bytes.Repeat([]byte("Z"), size)
+		code, err := Encode(data, MaxVersion)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range []struct {
+			name   string
+			text   string
+			invert bool
+		}{
+			{"Text", code.Text(), false},
+			{"Terminal", code.Terminal(), true},
+		} {
+			got, err := decode(fromHalfBlocks(t, tt.text, code.Size, tt.invert))
+			if err != nil {
+				t.Fatalf("%d bytes, %s: %v", size, tt.name, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("%d bytes, %s: got %q", size, tt.name, got)
+			}
+		}
+	}
+}
+
+func TestSequence(t *testing.T) {
+	const maxVersion = 5
+	rng := rand.New(rand.NewSource(3))
+	letters := make([]byte, 1000)
+	for i := range letters {
+		letters[i] = 'a' + byte(rng.Intn(26))
+	}
+
+	tests := []struct {
+		name  string
+		data  string
+		parts int
+	}{
+		{"fits in one code", string(letters[:Capacity(maxVersion)]), 1},
+		{"just over one code", string(letters[:Capacity(maxVersion)+1]), 2},
+		{"many codes", string(letters), 15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes, err := Sequence(tt.data, maxVersion)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(codes) != tt.parts {
+				t.Fatalf("%d codes, want %d", len(codes), tt.parts)
+			}
+
+			var joined strings.Builder
+			for i, code := range codes {
+				if code.Version > maxVersion {
+					t.Errorf("part %d is version %d", i+1, code.Version)
+				}
+				part, err := decode(modules(code))
+				if err != nil {
+					t.Fatalf("part %d: %v", i+1, err)
+				}
+				if len(codes) == 1 {
+					joined.Write(part)
+					continue
+				}
+				header := fmt.Sprintf("T2B %d/%d:", i+1, len(codes))
+				if !bytes.HasPrefix(part, []byte(header)) {
+					t.Fatalf("part %d starts %q, want %q", i+1, part[:min(len(part), 16)], header)
+				}
+				joined.Write(part[len(header):])
+			}
+			if joined.String() != tt.data {
+				t.Errorf("reassembled data differs")
+			}
+		})
+	}
+}
+
+func TestEncodeTooLarge(t *testing.T) {
+	for _, maxVersion := range []int{1, 10, MaxVersion} {
+		_, err := Encode(make([]byte, Capacity(maxVersion)+1), maxVersion)
+		if err == nil || !strings.Contains(err.Error(), "too large") {
+			t.Errorf("version %d: got %v, want a too large error", maxVersion, err)
+		}
+	}
+}
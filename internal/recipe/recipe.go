@@ -0,0 +1,253 @@
+package recipe
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"doc0x1/text2babe/internal/classic"
+	"doc0x1/text2babe/internal/crypto"
+)
+
+// A recipe is an ordered chain of reversible transforms written as
+// "gzip | aes-gcm | base58 | wrap 80". Encrypting applies the steps left to
+// right; decrypting inverts them right to left.
+
+// Step is one parsed transform
+type Step struct {
+	Name string
+	Arg  string
+	op   operation
+}
+
+type operation struct {
+	forward func(data []byte, arg string, key []byte) ([]byte, error)
+	inverse func(data []byte, arg string, key []byte) ([]byte, error)
+	// text reports whether the step outputs printable text
+	text bool
+}
+
+// Names lists the available steps and their argument syntax
+var Names = []string{
+	"gzip", "zlib", "aes-gcm", "hex", "base32", "base58", "base64", "base64url",
+	"binary", "wrap <width>", "<classic cipher> (e.g. caesar 3, vigenere lemon)",
+}
+
+var operations = map[string]operation{
+	"gzip":      {forward: gzipCompress, inverse: gzipDecompress},
+	"zlib":      {forward: zlibCompress, inverse: zlibDecompress},
+	"aes-gcm":   {forward: seal, inverse: open},
+	"hex":       {forward: encoder(hex.EncodeToString), inverse: decoder(hex.DecodeString), text: true},
+	"base32":    {forward: encoder(base32.StdEncoding.EncodeToString), inverse: decoder(base32.StdEncoding.DecodeString), text: true},
+	"base58":    {forward: encoder(encodeBase58), inverse: decoder(decodeBase58), text: true},
+	"base64":    {forward: encoder(base64.StdEncoding.EncodeToString), inverse: decoder(base64.StdEncoding.DecodeString), text: true},
+	"base64url": {forward: encoder(base64.RawURLEncoding.EncodeToString), inverse: decoder(base64.RawURLEncoding.DecodeString), text: true},
+	"binary":    {forward: encoder(encodeBinary), inverse: decoder(decodeBinary), text: true},
+	"wrap":      {forward: wrap, inverse: unwrap, text: true},
+}
+
+// Parse splits a recipe spec into validated steps
+func Parse(spec string) ([]Step, error) {
+	var steps []Step
+	for i, part := range strings.Split(spec, "|") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("step %d is empty", i+1)
+		}
+		name := strings.ToLower(fields[0])
+		arg := strings.Join(fields[1:], " ")
+
+		op, ok := operations[name]
+		if !ok {
+			// Fall back to the classical ciphers ("caesar 3" or "caesar:3")
+			cipherSpec := name
+			if arg != "" {
+				cipherSpec += ":" + strings.ReplaceAll(arg, " ", ",")
+			}
+			if strings.Contains(name, ":") {
+				cipherSpec = fields[0]
+			}
+			c, err := classic.Parse(cipherSpec)
+			if err != nil {
+				return nil, fmt.Errorf("step %d: unknown step %q", i+1, fields[0])
+			}
+			steps = append(steps, Step{Name: c.Name(), op: classicOperation(c)})
+			continue
+		}
+
+		if name == "wrap" {
+			if width, err := strconv.Atoi(arg); err != nil || width < 1 {
+				return nil, fmt.Errorf("step %d: wrap needs a positive width (wrap 80)", i+1)
+			}
+		}
+		steps = append(steps, Step{Name: name, Arg: arg, op: op})
+	}
+	return steps, nil
+}
+
+// String formats steps back into spec syntax
+func String(steps []Step) string {
+	parts := make([]string, len(steps))
+	for i, s := range steps {
+		parts[i] = strings.TrimSpace(s.Name + " " + s.Arg)
+	}
+	return strings.Join(parts, " | ")
+}
+
+// Apply runs the steps in order
+func Apply(steps []Step, data []byte, key []byte) ([]byte, error) {
+	for _, s := range steps {
+		var err error
+		if data, err = s.op.forward(data, s.Arg, key); err != nil {
+			return nil, fmt.Errorf("%s: %w", s.Name, err)
+		}
+	}
+	return data, nil
+}
+
+// Invert undoes the steps in reverse order
+func Invert(steps []Step, data []byte, key []byte) ([]byte, error) {
+	for i := len(steps) - 1; i >= 0; i-- {
+		s := steps[i]
+		var err error
+		if data, err = s.op.inverse(data, s.Arg, key); err != nil {
+			return nil, fmt.Errorf("undo %s: %w", s.Name, err)
+		}
+	}
+	return data, nil
+}
+
+// Encrypts reports whether any step uses the key
+func Encrypts(steps []Step) bool {
+	for _, s := range steps {
+		if s.Name == "aes-gcm" {
+			return true
+		}
+	}
+	return false
+}
+
+// TextOutput reports whether the recipe ends in printable text. Recipes that
+// end in raw bytes need an output format on top.
+func TextOutput(steps []Step) bool {
+	return len(steps) > 0 && steps[len(steps)-1].op.text
+}
+
+func classicOperation(c classic.Cipher) operation {
+	return operation{
+		forward: func(data []byte, _ string, _ []byte) ([]byte, error) { return c.Encode(data) },
+		inverse: func(data []byte, _ string, _ []byte) ([]byte, error) { return c.Decode(data) },
+		text:    c.Textual(),
+	}
+}
+
+func encoder(encode func([]byte) string) func([]byte, string, []byte) ([]byte, error) {
+	return func(data []byte, _ string, _ []byte) ([]byte, error) {
+		return []byte(encode(data)), nil
+	}
+}
+
+func decoder(decode func(string) ([]byte, error)) func([]byte, string, []byte) ([]byte, error) {
+	return func(data []byte, _ string, _ []byte) ([]byte, error) {
+		return decode(strings.Join(strings.Fields(string(data)), ""))
+	}
+}
+
+func seal(data []byte, _ string, key []byte) ([]byte, error) {
+	return crypto.Seal(key, data)
+}
+
+func open(data []byte, _ string, key []byte) ([]byte, error) {
+	return crypto.Open(key, data)
+}
+
+func gzipCompress(data []byte, _ string, _ []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gzipDecompress(data []byte, _ string, _ []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func zlibCompress(data []byte, _ string, _ []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func zlibDecompress(data []byte, _ string, _ []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// wrap breaks text into lines of the given width
+func wrap(data []byte, arg string, _ []byte) ([]byte, error) {
+	width, _ := strconv.Atoi(arg)
+	var out []byte
+	for len(data) > width {
+		out = append(out, data[:width]...)
+		out = append(out, '\n')
+		data = data[width:]
+	}
+	return append(out, data...), nil
+}
+
+// unwrap removes the line breaks added by wrap, including CRLF ones that
+// picked up a carriage return in transit; other whitespace is data
+func unwrap(data []byte, _ string, _ []byte) ([]byte, error) {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(data, []byte("\n"), nil), nil
+}
+
+func encodeBinary(data []byte) string {
+	var b strings.Builder
+	for _, c := range data {
+		fmt.Fprintf(&b, "%08b", c)
+	}
+	return b.String()
+}
+
+func decodeBinary(s string) ([]byte, error) {
+	if len(s)%8 != 0 {
+		return nil, fmt.Errorf("binary length must be a multiple of 8")
+	}
+	out := make([]byte, len(s)/8)
+	for i := range out {
+		v, err := strconv.ParseUint(s[i*8:i*8+8], 2, 8)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = byte(v)
+	}
+	return out, nil
+}
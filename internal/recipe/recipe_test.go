@@ -0,0 +1,156 @@
+package recipe
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func testKey() []byte {
+	return bytes.Repeat([]byte{0x42}, 32)
+}
+
+func TestRecipeRoundTrip(t *testing.T) {
+	tests := []struct {
+		spec string
+		text bool // ends in printable text
+	}{
+		{"gzip | aes-gcm | base58 | wrap 80", true},
+		{"zlib | base64url", true},
+		{"aes-gcm | hex | wrap 16", true},
+		{"base32", true},
+		{"base64 | binary", true},
+		{"gzip | aes-gcm", false},
+		{"caesar 3 | base64", true},
+		{"vigenere lemon | rot47 | gzip", false},
+		{"atbash | affine 5 8 | hex", true},
+	}
+	random := make([]byte, 200)
+	rand.New(rand.NewSource(1)).Read(random)
+	inputs := map[string][]byte{
+		"text":       []byte("Attack at dawn! Bring 3 torches, 2 ropes & some snacks."),
+		"empty":      {},
+		"zero bytes": make([]byte, 40),
+		"random":     random,
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			steps, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := TextOutput(steps); got != tt.text {
+				t.Errorf("TextOutput = %v, want %v", got, tt.text)
+			}
+			if got := Encrypts(steps); got != strings.Contains(tt.spec, "aes-gcm") {
+				t.Errorf("Encrypts = %v", got)
+			}
+
+			// The formatted recipe parses back to the same steps
+			again, err := Parse(String(steps))
+			if err != nil {
+				t.Fatalf("reparsing %q: %v", String(steps), err)
+			}
+			if String(again) != String(steps) {
+				t.Errorf("String round trip: %q became %q", String(steps), String(again))
+			}
+
+			for name, data := range inputs {
+				encoded, err := Apply(steps, data, testKey())
+				if err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+				got, err := Invert(steps, encoded, testKey())
+				if err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+				if !bytes.Equal(got, data) {
+					t.Errorf("%s: got %q, want %q", name, got, data)
+				}
+			}
+		})
+	}
+}
+
+// Unwrapping only removes the inserted line breaks, even when they were
+// converted to CRLF in transit
+func TestWrapKeepsSpaces(t *testing.T) {
+	steps, err := Parse("wrap 10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello world, this text has spaces\tand tabs")
+	wrapped, err := Apply(steps, data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, encoded := range [][]byte{wrapped, bytes.ReplaceAll(wrapped, []byte("\n"), []byte("\r\n"))} {
+		got, err := Invert(steps, encoded, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("got %q, want %q", got, data)
+		}
+	}
+}
+
+func TestInvertWrongKey(t *testing.T) {
+	steps, err := Parse("aes-gcm | base64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := Apply(steps, []byte("secret"), testKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Invert(steps, encoded, bytes.Repeat([]byte{1}, 32)); err == nil {
+		t.Errorf("decrypted %q with the wrong key", got)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string // part of the error message
+	}{
+		{"", "step 1 is empty"},
+		{"gzip ||", "step 2 is empty"},
+		{"gzip | nosuchstep", `unknown step "nosuchstep"`},
+		{"wrap", "positive width"},
+		{"base64 | wrap 0", "step 2: wrap needs"},
+		{"wrap eighty", "positive width"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := Parse(tt.spec)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want an error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBase58(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{[]byte("hello world"), "StV1DL6CwTryKyV"},
+		{[]byte{0, 0, 1}, "112"},
+		{[]byte{}, ""},
+	}
+	for _, tt := range tests {
+		if got := encodeBase58(tt.data); got != tt.want {
+			t.Errorf("encodeBase58(%q) = %q, want %q", tt.data, got, tt.want)
+		}
+		got, err := decodeBase58(tt.want)
+		if err != nil || !bytes.Equal(got, tt.data) {
+			t.Errorf("decodeBase58(%q) = %q, %v; want %q", tt.want, got, err, tt.data)
+		}
+	}
+	if _, err := decodeBase58("0OIl"); err == nil {
+		t.Error("decodeBase58 accepted characters outside the alphabet")
+	}
+}
@@ -0,0 +1,223 @@
+package rs
+
+import "fmt"
+
+// Reed-Solomon coding over GF(256) with the 0x11d primitive polynomial,
+// the same field and generator convention used by QR codes.
+
+var (
+	expTable [512]byte
+	logTable [256]byte
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		expTable[i] = byte(x)
+		logTable[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < 512; i++ {
+		expTable[i] = expTable[i-255]
+	}
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+int(logTable[b])]
+}
+
+// generator returns the generator polynomial with roots α^0..α^(n-1),
+// highest degree coefficient first
+func generator(n int) []byte {
+	g := []byte{1}
+	for i := 0; i < n; i++ {
+		next := make([]byte, len(g)+1)
+		for j, c := range g {
+			next[j] ^= c
+			next[j+1] ^= mul(c, expTable[i])
+		}
+		g = next
+	}
+	return g
+}
+
+// Encode returns the n parity bytes for data
+func Encode(data []byte, n int) []byte {
+	gen := generator(n)
+	rem := make([]byte, n)
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[n-1] = 0
+		for i := 0; i < n; i++ {
+			rem[i] ^= mul(gen[i+1], factor)
+		}
+	}
+	return rem
+}
+
+func div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])+255-int(logTable[b]))%255]
+}
+
+// Polynomials below are highest degree coefficient first, like codewords
+
+func polyEval(p []byte, x byte) byte {
+	y := p[0]
+	for _, c := range p[1:] {
+		y = mul(y, x) ^ c
+	}
+	return y
+}
+
+func polyScale(p []byte, x byte) []byte {
+	out := make([]byte, len(p))
+	for i, c := range p {
+		out[i] = mul(c, x)
+	}
+	return out
+}
+
+func polyAdd(p, q []byte) []byte {
+	out := make([]byte, max(len(p), len(q)))
+	for i, c := range p {
+		out[i+len(out)-len(p)] = c
+	}
+	for i, c := range q {
+		out[i+len(out)-len(q)] ^= c
+	}
+	return out
+}
+
+func polyMul(p, q []byte) []byte {
+	out := make([]byte, len(p)+len(q)-1)
+	for i, a := range p {
+		for j, b := range q {
+			out[i+j] ^= mul(a, b)
+		}
+	}
+	return out
+}
+
+// syndromes evaluates the codeword at each generator root; all zero means
+// no detectable errors. A leading zero keeps indices aligned with Forney.
+func syndromes(codeword []byte, n int) ([]byte, bool) {
+	synd := make([]byte, n+1)
+	clean := true
+	for i := 0; i < n; i++ {
+		synd[i+1] = polyEval(codeword, expTable[i])
+		if synd[i+1] != 0 {
+			clean = false
+		}
+	}
+	return synd, clean
+}
+
+// errorLocator runs Berlekamp-Massey over the syndromes
+func errorLocator(synd []byte, n int) []byte {
+	loc, old := []byte{1}, []byte{1}
+	for i := 0; i < n; i++ {
+		k := i + 1
+		delta := synd[k]
+		for j := 1; j < len(loc) && k-j >= 0; j++ {
+			delta ^= mul(loc[len(loc)-1-j], synd[k-j])
+		}
+		old = append(old, 0)
+		if delta != 0 {
+			if len(old) > len(loc) {
+				next := polyScale(old, delta)
+				old = polyScale(loc, div(1, delta))
+				loc = next
+			}
+			loc = polyAdd(loc, polyScale(old, delta))
+		}
+	}
+	for len(loc) > 1 && loc[0] == 0 {
+		loc = loc[1:]
+	}
+	return loc
+}
+
+// Decode corrects up to n/2 symbol errors in a codeword produced by
+// appending Encode's parity to data. It returns the data portion and the
+// number of corrected symbols.
+func Decode(codeword []byte, n int) ([]byte, int, error) {
+	if len(codeword) <= n || len(codeword) > 255 {
+		return nil, 0, fmt.Errorf("invalid codeword length %d for %d parity symbols", len(codeword), n)
+	}
+
+	synd, clean := syndromes(codeword, n)
+	if clean {
+		return codeword[:len(codeword)-n], 0, nil
+	}
+
+	loc := errorLocator(synd, n)
+	errs := len(loc) - 1
+	if errs*2 > n {
+		return nil, 0, fmt.Errorf("too many errors to correct")
+	}
+
+	// Chien search: roots of the reversed locator give the error positions
+	rev := make([]byte, len(loc))
+	for i, c := range loc {
+		rev[len(loc)-1-i] = c
+	}
+	var positions []int
+	for i := 0; i < len(codeword); i++ {
+		if polyEval(rev, expTable[i]) == 0 {
+			positions = append(positions, len(codeword)-1-i)
+		}
+	}
+	if len(positions) != errs {
+		return nil, 0, fmt.Errorf("too many errors to correct")
+	}
+
+	// Forney: compute each error magnitude from the evaluator polynomial
+	coefPos := make([]int, len(positions))
+	errataLoc := []byte{1}
+	for i, p := range positions {
+		coefPos[i] = len(codeword) - 1 - p
+		errataLoc = polyMul(errataLoc, []byte{expTable[coefPos[i]], 1})
+	}
+	syndRev := make([]byte, len(synd))
+	for i, c := range synd {
+		syndRev[len(synd)-1-i] = c
+	}
+	product := polyMul(syndRev, errataLoc)
+	evaluator := product[len(product)-len(errataLoc):]
+
+	X := make([]byte, len(coefPos))
+	for i, c := range coefPos {
+		X[i] = expTable[c%255]
+	}
+
+	corrected := append([]byte(nil), codeword...)
+	for i, xi := range X {
+		xiInv := div(1, xi)
+		prime := byte(1)
+		for j, xj := range X {
+			if j != i {
+				prime = mul(prime, 1^mul(xiInv, xj))
+			}
+		}
+		if prime == 0 {
+			return nil, 0, fmt.Errorf("too many errors to correct")
+		}
+		y := mul(xi, polyEval(evaluator, xiInv))
+		corrected[positions[i]] ^= div(y, prime)
+	}
+
+	if _, clean := syndromes(corrected, n); !clean {
+		return nil, 0, fmt.Errorf("too many errors to correct")
+	}
+	return corrected[:len(corrected)-n], errs, nil
+}
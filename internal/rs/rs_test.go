@@ -0,0 +1,90 @@
+package rs
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// corrupt returns a copy of codeword with count bytes at distinct random
+// positions changed
+func corrupt(rng *rand.Rand, codeword []byte, count int) []byte {
+	damaged := append([]byte(nil), codeword...)
+	for _, pos := range rng.Perm(len(codeword))[:count] {
+		damaged[pos] ^= byte(1 + rng.Intn(255))
+	}
+	return damaged
+}
+
+func sample(rng *rand.Rand, size int) []byte {
+	data := make([]byte, size)
+	rng.Read(data)
+	return data
+}
+
+var codes = []struct {
+	name    string
+	dataLen int
+	n       int
+}{
+	{"short block", 10, 4},
+	{"odd parity", 40, 7},
+	{"medium block", 100, 16},
+	{"full block", 223, 32},
+	{"maximum parity", 127, 128},
+}
+
+func TestDecodeCorrectsUpToHalfParity(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, tt := range codes {
+		t.Run(tt.name, func(t *testing.T) {
+			data := sample(rng, tt.dataLen)
+			codeword := append(append([]byte(nil), data...), Encode(data, tt.n)...)
+			for errs := 0; errs <= tt.n/2; errs++ {
+				got, fixed, err := Decode(corrupt(rng, codeword, errs), tt.n)
+				if err != nil {
+					t.Fatalf("%d errors: %v", errs, err)
+				}
+				if fixed != errs {
+					t.Errorf("%d errors: reported %d corrections", errs, fixed)
+				}
+				if !bytes.Equal(got, data) {
+					t.Fatalf("%d errors: decoded data differs", errs)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsTooManyErrors(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, tt := range codes {
+		t.Run(tt.name, func(t *testing.T) {
+			data := sample(rng, tt.dataLen)
+			codeword := append(append([]byte(nil), data...), Encode(data, tt.n)...)
+			for _, errs := range []int{tt.n/2 + 1, tt.n} {
+				if _, _, err := Decode(corrupt(rng, codeword, errs), tt.n); err == nil {
+					t.Errorf("%d errors with %d parity bytes: no error", errs, tt.n)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsBadLength(t *testing.T) {
+	tests := []struct {
+		name   string
+		length int
+		n      int
+	}{
+		{"no data", 4, 4},
+		{"longer than a block", 256, 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := Decode(make([]byte, tt.length), tt.n); err == nil {
+				t.Errorf("Decode accepted a %d byte codeword with %d parity bytes", tt.length, tt.n)
+			}
+		})
+	}
+}
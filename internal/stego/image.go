@@ -0,0 +1,96 @@
+package stego
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+// Payloads are stored in the least significant bit of each R, G and B
+// channel, row by row, preceded by a 4-byte big-endian length
+
+const lengthHeader = 4
+
+// ImageCapacity returns how many payload bytes fit in img
+func ImageCapacity(img image.Image) int {
+	b := img.Bounds()
+	capacity := b.Dx()*b.Dy()*3/8 - lengthHeader
+	if capacity < 0 {
+		return 0
+	}
+	return capacity
+}
+
+// EmbedImage returns a copy of img with payload hidden in its pixel LSBs
+func EmbedImage(img image.Image, payload []byte) (*image.NRGBA, error) {
+	if capacity := ImageCapacity(img); len(payload) > capacity {
+		return nil, fmt.Errorf("payload too large for image (%d bytes, capacity %d)", len(payload), capacity)
+	}
+
+	out := toNRGBA(img)
+	stream := make([]byte, lengthHeader, lengthHeader+len(payload))
+	binary.BigEndian.PutUint32(stream, uint32(len(payload)))
+	stream = append(stream, payload...)
+
+	bit := 0
+	for _, i := range channelOffsets(out) {
+		if bit >= len(stream)*8 {
+			break
+		}
+		value := (stream[bit/8] >> (7 - bit%8)) & 1
+		out.Pix[i] = out.Pix[i]&^1 | value
+		bit++
+	}
+	return out, nil
+}
+
+// ExtractImage reads a payload hidden by EmbedImage
+func ExtractImage(img image.Image) ([]byte, error) {
+	src := toNRGBA(img)
+	offsets := channelOffsets(src)
+
+	readBytes := func(start, n int) []byte {
+		data := make([]byte, n)
+		for bit := 0; bit < n*8; bit++ {
+			data[bit/8] = data[bit/8]<<1 | src.Pix[offsets[start*8+bit]]&1
+		}
+		return data
+	}
+
+	if len(offsets) < lengthHeader*8 {
+		return nil, fmt.Errorf("image too small to hold a payload")
+	}
+	length := int(binary.BigEndian.Uint32(readBytes(0, lengthHeader)))
+	if length == 0 || length > ImageCapacity(img) {
+		return nil, fmt.Errorf("no hidden payload found in image")
+	}
+	return readBytes(lengthHeader, length), nil
+}
+
+// channelOffsets lists the Pix indexes of every R, G and B sample in order
+func channelOffsets(img *image.NRGBA) []int {
+	b := img.Bounds()
+	offsets := make([]int, 0, b.Dx()*b.Dy()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			i := img.PixOffset(x, y)
+			offsets = append(offsets, i, i+1, i+2)
+		}
+	}
+	return offsets
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	out := image.NewNRGBA(b)
+	if src, ok := img.(*image.NRGBA); ok {
+		// Copy rows directly; draw would round-trip through premultiplied alpha
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			copy(out.Pix[out.PixOffset(b.Min.X, y):out.PixOffset(b.Max.X, y)], src.Pix[src.PixOffset(b.Min.X, y):src.PixOffset(b.Max.X, y)])
+		}
+		return out
+	}
+	draw.Draw(out, b, img, b.Min, draw.Src)
+	return out
+}
@@ -0,0 +1,140 @@
+package stego
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// photo returns an image with random pixels, a stand-in for a real cover
+func photo(rng *rand.Rand, w, h int, alpha bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	rng.Read(img.Pix)
+	if !alpha {
+		for i := 3; i < len(img.Pix); i += 4 {
+			img.Pix[i] = 0xff
+		}
+	}
+	return img
+}
+
+// viaPNG encodes img as a PNG file and decodes it again, as when the
+// stego image is saved and later opened
+func viaPNG(t *testing.T, img image.Image) image.Image {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestImageRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	gray := image.NewGray(image.Rect(0, 0, 40, 30))
+	rng.Read(gray.Pix)
+	white := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	draw.Draw(white, white.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	tests := []struct {
+		name  string
+		cover image.Image
+	}{
+		{"opaque", photo(rng, 64, 48, false)},
+		{"semi-transparent", photo(rng, 64, 48, true)},
+		{"grayscale", gray},
+		{"plain white", white},
+		{"sub-image", photo(rng, 50, 50, false).SubImage(image.Rect(10, 5, 45, 40))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capacity := ImageCapacity(tt.cover)
+			b := tt.cover.Bounds()
+			if want := b.Dx()*b.Dy()*3/8 - 4; capacity != want {
+				t.Fatalf("capacity %d, want %d", capacity, want)
+			}
+
+			full := make([]byte, capacity)
+			rng.Read(full)
+			for _, payload := range [][]byte{[]byte("x"), []byte("meet at the docks"), full} {
+				stego, err := EmbedImage(tt.cover, payload)
+				if err != nil {
+					t.Fatalf("%d bytes: %v", len(payload), err)
+				}
+
+				// Every channel is within one step of the cover and alpha is untouched
+				for y := b.Min.Y; y < b.Max.Y; y++ {
+					for x := b.Min.X; x < b.Max.X; x++ {
+						want := color.NRGBAModel.Convert(tt.cover.At(x, y)).(color.NRGBA)
+						got := stego.NRGBAAt(x, y)
+						if diff(got.R, want.R) > 1 || diff(got.G, want.G) > 1 || diff(got.B, want.B) > 1 || got.A != want.A {
+							t.Fatalf("pixel (%d, %d) changed from %v to %v", x, y, want, got)
+						}
+					}
+				}
+
+				for name, img := range map[string]image.Image{"in memory": stego, "through PNG": viaPNG(t, stego)} {
+					got, err := ExtractImage(img)
+					if err != nil {
+						t.Fatalf("%d bytes %s: %v", len(payload), name, err)
+					}
+					if !bytes.Equal(got, payload) {
+						t.Fatalf("%d bytes %s: payload differs", len(payload), name)
+					}
+				}
+			}
+		})
+	}
+}
+
+func diff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func TestEmbedImageTooLarge(t *testing.T) {
+	cover := photo(rand.New(rand.NewSource(2)), 20, 20, false)
+	_, err := EmbedImage(cover, make([]byte, ImageCapacity(cover)+1))
+	if err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("got %v, want a payload too large error", err)
+	}
+}
+
+func TestExtractImageWithoutPayload(t *testing.T) {
+	white := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	draw.Draw(white, white.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	black := image.NewGray(image.Rect(0, 0, 32, 32))
+
+	tests := []struct {
+		name string
+		img  image.Image
+		want string // part of the error message
+	}{
+		{"white", white, "no hidden payload"},
+		{"black", black, "no hidden payload"},
+		{"random pixels", photo(rand.New(rand.NewSource(3)), 32, 32, false), "no hidden payload"},
+		{"too small", image.NewGray(image.Rect(0, 0, 3, 3)), "too small"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractImage(tt.img)
+			if err == nil {
+				t.Fatalf("extracted %d bytes from a clean image", len(got))
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not mention %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package stego
+
+import (
+	"strings"
+)
+
+// Zero-width characters, each carrying two bits
+var zeroWidth = [4]rune{
+	'\u200b', // ZWSP = 00
+	'\u200c', // ZWNJ = 01
+	'\u200d', // ZWJ  = 10
+	'\u2060', // WJ   = 11
+}
+
+// Hidden payloads start with this marker so stray zero-width characters
+// (emoji ZWJ sequences, pasted web text) are not mistaken for data
+var textMagic = []byte("t2b")
+
+func symbolValue(r rune) int {
+	for i, z := range zeroWidth {
+		if r == z {
+			return i
+		}
+	}
+	return -1
+}
+
+// HideText embeds payload as zero-width characters after the first word of cover
+func HideText(cover string, payload []byte) string {
+	var hidden strings.Builder
+	for _, b := range append(append([]byte{}, textMagic...), payload...) {
+		for shift := 6; shift >= 0; shift -= 2 {
+			hidden.WriteRune(zeroWidth[(b>>shift)&3])
+		}
+	}
+
+	if i := strings.IndexByte(cover, ' '); i >= 0 {
+		return cover[:i] + hidden.String() + cover[i:]
+	}
+	return cover + hidden.String()
+}
+
+// ExtractText returns the payload hidden in text, if any
+func ExtractText(text string) ([]byte, bool) {
+	// Use the longest run of zero-width characters
+	var best, run []int
+	flush := func() {
+		if len(run) > len(best) {
+			best = run
+		}
+		run = nil
+	}
+	for _, r := range text {
+		if v := symbolValue(r); v >= 0 {
+			run = append(run, v)
+		} else {
+			flush()
+		}
+	}
+	flush()
+
+	if len(best) == 0 || len(best)%4 != 0 {
+		return nil, false
+	}
+
+	data := make([]byte, len(best)/4)
+	for i := range data {
+		for _, v := range best[i*4 : i*4+4] {
+			data[i] = data[i]<<2 | byte(v)
+		}
+	}
+	if len(data) <= len(textMagic) || string(data[:len(textMagic)]) != string(textMagic) {
+		return nil, false
+	}
+	return data[len(textMagic):], true
+}
+
+// ContainsHidden reports whether text carries a zero-width payload
+func ContainsHidden(text string) bool {
+	_, ok := ExtractText(text)
+	return ok
+}
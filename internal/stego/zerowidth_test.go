@@ -0,0 +1,84 @@
+package stego
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// visible removes the zero-width characters from text
+func visible(text string) string {
+	return strings.Map(func(r rune) rune {
+		if symbolValue(r) >= 0 {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	covers := []string{
+		"see you at lunch",
+		"ok",
+		"",
+		"family photo 👨\u200d👩\u200d👧 from the weekend",
+		"👨\u200d👩\u200d👧\u200d👦 ok",
+	}
+	payloads := [][]byte{[]byte("x"), []byte("meet at the docks"), all}
+	for _, cover := range covers {
+		for _, payload := range payloads {
+			text := HideText(cover, payload)
+			if visible(text) != visible(cover) {
+				t.Errorf("cover %q: visible text changed to %q", cover, visible(text))
+			}
+			if !ContainsHidden(text) {
+				t.Errorf("cover %q, %d bytes: ContainsHidden = false", cover, len(payload))
+			}
+			got, ok := ExtractText(text)
+			if !ok {
+				t.Fatalf("cover %q, %d bytes: nothing extracted", cover, len(payload))
+			}
+			if !bytes.Equal(got, payload) {
+				t.Fatalf("cover %q: got %q, want %q", cover, got, payload)
+			}
+		}
+	}
+}
+
+func TestTextHiddenAfterFirstWord(t *testing.T) {
+	text := HideText("see you at lunch", []byte("hi"))
+	first, rest, _ := strings.Cut(text, " ")
+	if !strings.HasPrefix(first, "see") || visible(first) != "see" || rest != "you at lunch" {
+		t.Errorf("payload not placed after the first word: %q", text)
+	}
+}
+
+func TestExtractTextWithoutPayload(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"plain text", "nothing to see here"},
+		{"empty", ""},
+		{"emoji ZWJ sequence", "👩\u200d💻 at work"},
+		{"stray zero-width space", "copied\u200bfrom a web page"},
+		{"run not a whole byte", "a\u200b\u200c\u200db"},
+		// Four whole bytes that don't start with the marker
+		{"no marker", "a" + strings.Repeat("\u200c\u200b\u200d\u2060", 4) + "b"},
+		{"marker only", HideText("a b", nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, ok := ExtractText(tt.text); ok {
+				t.Errorf("extracted %q from %q", got, tt.text)
+			}
+			if ContainsHidden(tt.text) {
+				t.Error("ContainsHidden = true")
+			}
+		})
+	}
+}
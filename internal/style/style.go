@@ -50,8 +50,9 @@ func Prompt() string {
 		Blue.Sprint("❯"))
 }
 
-// PromptWithMode creates a prompt that shows the current mode
-func PromptWithMode(mode string) string {
+// PromptWithMode creates a prompt that shows the current mode and the
+// active profile, if any
+func PromptWithMode(mode, profile string) string {
 	var lockEmoji string
 
 	if mode == "decrypt" {
@@ -60,9 +61,14 @@ func PromptWithMode(mode string) string {
 		lockEmoji = "🔒" // Lock for encrypt
 	}
 
-	return fmt.Sprintf("%s%s%s %s%s%s %s ",
+	if profile != "" {
+		profile = " " + Yellow.Sprint(profile)
+	}
+
+	return fmt.Sprintf("%s%s%s%s %s%s%s %s ",
 		Gray.Sprint("["),
 		Cyan.Sprint(lockEmoji),
+		profile,
 		Gray.Sprint("]"),
 		Green.Sprint("me"),
 		Gray.Sprint("@"),
@@ -15,10 +15,12 @@ type Prompt struct {
 
 func New() (*Prompt, error) {
 	rl, err := readline.NewEx(&readline.Config{
-		Prompt:          style.PromptWithMode("encrypt"), // Start with encrypt mode
+		Prompt:          style.PromptWithMode("encrypt", activeProfile()), // Start with encrypt mode
 		AutoComplete:    completer,
 		InterruptPrompt: "^C",
 		EOFPrompt:       "exit",
+		// Lines are added with AddHistory, so the shell can leave out secrets
+		DisableAutoSaveHistory: true,
 	})
 	if err != nil {
 		return nil, err
@@ -27,9 +29,9 @@ func New() (*Prompt, error) {
 	return &Prompt{rl: rl}, nil
 }
 
-// UpdatePrompt changes the prompt to reflect current mode
+// UpdatePrompt changes the prompt to reflect current mode and profile
 func (p *Prompt) UpdatePrompt(mode string) {
-	p.rl.SetPrompt(style.PromptWithMode(mode))
+	p.rl.SetPrompt(style.PromptWithMode(mode, activeProfile()))
 }
 
 func (p *Prompt) ReadLine() (string, error) {
@@ -43,6 +45,25 @@ func (p *Prompt) ReadLine() (string, error) {
 	return strings.TrimSpace(line), err
 }
 
+// AddHistory makes line available to the up arrow. History is kept in
+// memory only.
+func (p *Prompt) AddHistory(line string) {
+	p.rl.SaveHistory(line)
+}
+
+// ReadPassword reads a line with the input masked. Ctrl-C or Ctrl-D
+// returns io.EOF.
+func (p *Prompt) ReadPassword(prompt string) (string, error) {
+	cfg := p.rl.GenPasswordConfig()
+	cfg.Prompt = prompt
+	cfg.MaskRune = '*'
+	line, err := p.rl.ReadPasswordWithConfig(cfg)
+	if err == readline.ErrInterrupt {
+		return "", io.EOF
+	}
+	return string(line), err
+}
+
 func (p *Prompt) Close() error {
 	return p.rl.Close()
 }
@@ -51,10 +72,58 @@ func (p *Prompt) SetPrompt(prompt string) {
 	p.rl.SetPrompt(prompt)
 }
 
+// profileName supplies the active profile for the prompt
+var profileName func() string
+
+// SetProfileName registers the source of the active profile name
+func SetProfileName(fn func() string) {
+	profileName = fn
+}
+
+func activeProfile() string {
+	if profileName == nil {
+		return ""
+	}
+	return profileName()
+}
+
+// profileNames supplies stored profile names for completion
+var profileNames func() []string
+
+// SetProfileNames registers the source of profile names for tab completion
+func SetProfileNames(fn func() []string) {
+	profileNames = fn
+}
+
+func listProfileNames(string) []string {
+	if profileNames == nil {
+		return nil
+	}
+	return profileNames()
+}
+
+// recipeNames supplies stored recipe names for completion
+var recipeNames func() []string
+
+// SetRecipeNames registers the source of recipe names for tab completion
+func SetRecipeNames(fn func() []string) {
+	recipeNames = fn
+}
+
+func listRecipeNames(string) []string {
+	if recipeNames == nil {
+		return nil
+	}
+	return recipeNames()
+}
+
 var completer = readline.NewPrefixCompleter(
 	readline.PcItem("help"),
 	readline.PcItem("settings"),
-	readline.PcItem("config"),
+	readline.PcItem("config",
+		readline.PcItem("save"),
+		readline.PcItem("reset"),
+	),
 	readline.PcItem("mode",
 		readline.PcItem("encrypt"),
 		readline.PcItem("decrypt"),
@@ -68,10 +137,33 @@ var completer = readline.NewPrefixCompleter(
 			readline.PcItem("encrypt"),
 			readline.PcItem("decrypt"),
 		),
+		readline.PcItem("datatype",
+			readline.PcItem("text"),
+			readline.PcItem("binary"),
+		),
 		readline.PcItem("output",
 			readline.PcItem("hex"),
 			readline.PcItem("base64"),
 			readline.PcItem("binary"),
+			readline.PcItem("armor"),
+			readline.PcItem("hexdump"),
+			readline.PcItem("url"),
+			readline.PcItem("html"),
+			readline.PcItem("unicode"),
+			readline.PcItem("qp"),
+		),
+		readline.PcItem("input",
+			readline.PcItem("auto"),
+			readline.PcItem("armor"),
+			readline.PcItem("hexdump"),
+			readline.PcItem("url"),
+			readline.PcItem("html"),
+			readline.PcItem("unicode"),
+			readline.PcItem("qp"),
+			readline.PcItem("hex"),
+			readline.PcItem("base64"),
+			readline.PcItem("binary"),
+			readline.PcItem("text"),
 		),
 		readline.PcItem("discord",
 			readline.PcItem("on"),
@@ -89,23 +181,110 @@ var completer = readline.NewPrefixCompleter(
 			readline.PcItem("true"),
 			readline.PcItem("false"),
 		),
+		readline.PcItem("cipher",
+			readline.PcItem("aes-gcm"),
+			readline.PcItem("chacha20-poly1305"),
+		),
+		readline.PcItem("clipboard",
+			readline.PcItem("on"),
+			readline.PcItem("off"),
+		),
 		readline.PcItem("discord-id"),
 		readline.PcItem("dmid"),
+		readline.PcItem("key",
+			readline.PcItem("file:"),
+			readline.PcItem("env:"),
+			readline.PcItem("command:"),
+			readline.PcItem("default"),
+		),
+		readline.PcItem("qr",
+			readline.PcItem("on"),
+			readline.PcItem("off"),
+		),
+		readline.PcItem("fec",
+			readline.PcItem("off"),
+			readline.PcItem("low"),
+			readline.PcItem("medium"),
+			readline.PcItem("high"),
+		),
+		readline.PcItem("stego",
+			readline.PcItem("off"),
+		),
+		readline.PcItem("profile",
+			readline.PcItemDynamic(listProfileNames),
+		),
+		readline.PcItem("recipe",
+			readline.PcItemDynamic(listRecipeNames),
+			readline.PcItem("off"),
+		),
+		readline.PcItem("classic",
+			readline.PcItem("caesar:"),
+			readline.PcItem("rot13"),
+			readline.PcItem("rot47"),
+			readline.PcItem("atbash"),
+			readline.PcItem("vigenere:"),
+			readline.PcItem("affine:"),
+			readline.PcItem("railfence:"),
+			readline.PcItem("playfair:"),
+			readline.PcItem("xor:"),
+			readline.PcItem("morse"),
+			readline.PcItem("off"),
+		),
 	),
 	readline.PcItem("toggle",
 		readline.PcItem("mode"),
+		readline.PcItem("datatype"),
 		readline.PcItem("output"),
 		readline.PcItem("discord"),
 		readline.PcItem("encryption"),
+		readline.PcItem("qr"),
 	),
 	readline.PcItem("encrypt"),
 	readline.PcItem("decrypt"),
-	readline.PcItem("key"),
+	readline.PcItem("save"),
+	readline.PcItem("key",
+		readline.PcItem("--file"),
+		readline.PcItem("--env"),
+		readline.PcItem("--command"),
+		readline.PcItem("--prompt"),
+		readline.PcItem("backup"),
+		readline.PcItem("restore"),
+	),
 	readline.PcItem("discord",
 		readline.PcItem("test"),
 		readline.PcItem("fetch"),
 		readline.PcItem("decrypt"),
 	),
+	readline.PcItem("stego",
+		readline.PcItem("embed"),
+		readline.PcItem("extract"),
+		readline.PcItem("capacity"),
+	),
+	readline.PcItem("analyze",
+		readline.PcItem("crack"),
+		readline.PcItem("freq"),
+	),
+	readline.PcItem("detect"),
+	readline.PcItem("recipe",
+		readline.PcItem("add"),
+		readline.PcItem("list"),
+		readline.PcItem("remove",
+			readline.PcItemDynamic(listRecipeNames),
+		),
+	),
+	readline.PcItem("profile",
+		readline.PcItem("create"),
+		readline.PcItem("use",
+			readline.PcItemDynamic(listProfileNames),
+		),
+		readline.PcItem("list"),
+		readline.PcItem("delete",
+			readline.PcItemDynamic(listProfileNames),
+		),
+	),
+	readline.PcItem("magic",
+		readline.PcItem("--aes"),
+	),
 	readline.PcItem("exit"),
 	readline.PcItem("quit"),
 )